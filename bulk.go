@@ -0,0 +1,221 @@
+package gofilestorer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// BatchError is returned by bulk operations when some of the items fail, in
+// which case none of the changes are applied. Errors maps the index of each
+// failed item to its error.
+type BatchError struct {
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	return fmt.Sprintf("%s: %d items failed, item %d: %v", ErrorBatchFailed, len(indexes), indexes[0], e.Errors[indexes[0]])
+}
+
+func (e *BatchError) Unwrap() error {
+	return ErrorBatchFailed
+}
+
+// apply changes to a copy of the records and persist them in a single batch,
+// leaving the storer untouched when apply or the write fails. handBack is
+// called with the changes once they are applied, under the write lock.
+func (s *fileWriter[K, V]) applyBatch(ctx context.Context, apply func(r *records[K, V]) ([]change[K, V], error), handBack func([]change[K, V])) error {
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r := s.records.copy()
+	changes, err := apply(&r)
+	if err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		if err := s.persistRecords(r, changes); err != nil {
+			return err
+		}
+	}
+
+	if handBack != nil {
+		handBack(changes)
+	}
+	return nil
+}
+
+// hand the records changed by a batch back to the callers that passed data,
+// which has one record for each change
+func (s *fileWriter[K, V]) handBackAll(data []V, changes []change[K, V]) []V {
+	result := make([]V, len(data))
+	for i, c := range changes {
+		result[i] = s.handBack(data[i], c)
+	}
+	return result
+}
+
+// apply a change to each item, collecting the errors of failed items into a
+// *BatchError
+func applyEach[K comparable, V any, T any](items []T, apply func(T) (change[K, V], error)) ([]change[K, V], error) {
+	changes := make([]change[K, V], 0, len(items))
+	errs := map[int]error{}
+	for i, item := range items {
+		c, err := apply(item)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		changes = append(changes, c)
+	}
+
+	if len(errs) > 0 {
+		return nil, &BatchError{Errors: errs}
+	}
+	return changes, nil
+}
+
+// update the record with the ID of data, or create it when it does not exist.
+// A record without an ID gets a new one. A soft deleted record is neither
+// updated nor created again, ErrorDataDeleted is returned until it is restored
+// or purged.
+func (s *fileWriter[K, V]) Upsert(data V) (V, error) {
+	return s.UpsertContext(context.Background(), data)
+}
+
+// upsert a record like Upsert, giving up when ctx is done before the record is
+// written
+func (s *fileWriter[K, V]) UpsertContext(ctx context.Context, data V) (V, error) {
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return *new(V), err
+	}
+	defer unlock()
+
+	c, err := s.upsert(&s.records, data)
+	if err != nil {
+		return *new(V), err
+	}
+	if err := s.persist(c); err != nil {
+		return *new(V), err
+	}
+
+	return s.handBack(data, c), nil
+}
+
+// apply an upsert to r
+func (s *fileWriter[K, V]) upsert(r *records[K, V], data V) (change[K, V], error) {
+	id := data.GetID()
+	if id == *new(K) {
+		return s.create(r, data)
+	}
+	if old, ok := r.dataMap[id]; ok {
+		if !s.visible(old) {
+			return change[K, V]{}, ErrorDataDeleted
+		}
+		return s.update(r, id, data)
+	}
+	return s.createWithID(r, id, data)
+}
+
+// create all records and write them to file at once. When any record cannot
+// be created, none are and a *BatchError is returned. The records passed are
+// only changed once all of them are written.
+func (s *fileWriter[K, V]) CreateMany(data []V) ([]V, error) {
+	return s.CreateManyContext(context.Background(), data)
+}
+
+// create records like CreateMany, giving up when ctx is done before the
+// records are created
+func (s *fileWriter[K, V]) CreateManyContext(ctx context.Context, data []V) ([]V, error) {
+	var result []V
+	err := s.applyBatch(ctx, func(r *records[K, V]) ([]change[K, V], error) {
+		return applyEach(data, func(d V) (change[K, V], error) {
+			return s.create(r, d)
+		})
+	}, func(changes []change[K, V]) {
+		result = s.handBackAll(data, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// update all records by their IDs and write them to file at once. When any
+// record cannot be updated, none are and a *BatchError is returned. The
+// records passed are only changed once all of them are written.
+func (s *fileWriter[K, V]) UpdateMany(data []V) ([]V, error) {
+	return s.UpdateManyContext(context.Background(), data)
+}
+
+// update records like UpdateMany, giving up when ctx is done before the
+// records are updated
+func (s *fileWriter[K, V]) UpdateManyContext(ctx context.Context, data []V) ([]V, error) {
+	var result []V
+	err := s.applyBatch(ctx, func(r *records[K, V]) ([]change[K, V], error) {
+		return applyEach(data, func(d V) (change[K, V], error) {
+			return s.update(r, d.GetID(), d)
+		})
+	}, func(changes []change[K, V]) {
+		result = s.handBackAll(data, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// delete all records with the given IDs and write the file at once. When any
+// record cannot be deleted, none are and a *BatchError is returned.
+func (s *fileWriter[K, V]) DeleteMany(ids []K) error {
+	return s.DeleteManyContext(context.Background(), ids)
+}
+
+// delete records like DeleteMany, giving up when ctx is done before the
+// records are deleted
+func (s *fileWriter[K, V]) DeleteManyContext(ctx context.Context, ids []K) error {
+	return s.applyBatch(ctx, func(r *records[K, V]) ([]change[K, V], error) {
+		return applyEach(ids, func(id K) (change[K, V], error) {
+			return s.delete(r, id)
+		})
+	}, nil)
+}
+
+// delete all records matching filter and write the file at once, returning
+// the number of deleted records
+func (s *fileWriter[K, V]) DeleteWhere(filter func(V) bool) (int, error) {
+	return s.DeleteWhereContext(context.Background(), filter)
+}
+
+// delete records like DeleteWhere, giving up when ctx is done before the
+// records are deleted
+func (s *fileWriter[K, V]) DeleteWhereContext(ctx context.Context, filter func(V) bool) (int, error) {
+	deleted := 0
+	err := s.applyBatch(ctx, func(r *records[K, V]) ([]change[K, V], error) {
+		ids := []K{}
+		for _, record := range r.data {
+			if s.visible(record) && filter(record) {
+				ids = append(ids, record.GetID())
+			}
+		}
+		deleted = len(ids)
+
+		return applyEach(ids, func(id K) (change[K, V], error) {
+			return s.delete(r, id)
+		})
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,162 @@
+package gofilestorer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterBulk(t *testing.T) {
+	newIdFunc := func(dataArray []*testJSONDataInt64, _ *testJSONDataInt64) int64 {
+		return int64(len(dataArray) + 1)
+	}
+	readFile := func(t *testing.T, fs afero.Fs) []*testJSONDataInt64 {
+		r, err := NewJSONReader[int64, *testJSONDataInt64](fs, "./int64.json")
+		assert.NoError(t, err)
+		read, err := r.ReadAll()
+		assert.NoError(t, err)
+		return read
+	}
+
+	t.Run("Upsert", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		// Update an existing record
+		_, err = s.Upsert(&testJSONDataInt64{ID: 1, Name: "updated"})
+		assert.NoError(t, err)
+
+		// Create a record with a new ID
+		_, err = s.Upsert(&testJSONDataInt64{ID: 10, Name: "ten"})
+		assert.NoError(t, err)
+
+		// Create a record without an ID
+		data, err := s.Upsert(&testJSONDataInt64{Name: "generated"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), data.ID)
+
+		read := readFile(t, fs)
+		assert.Len(t, read, 3)
+		assert.Equal(t, "updated", read[0].Name)
+		assert.Equal(t, int64(10), read[1].ID)
+	})
+
+	t.Run("CreateMany", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		data, err := s.CreateMany([]*testJSONDataInt64{{Name: "second"}, {Name: "third"}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), data[0].ID)
+		assert.Equal(t, int64(3), data[1].ID)
+		assert.Len(t, readFile(t, fs), 3)
+	})
+
+	t.Run("UpdateMany", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+		_, err = s.Create(&testJSONDataInt64{Name: "second"})
+		assert.NoError(t, err)
+
+		_, err = s.UpdateMany([]*testJSONDataInt64{{ID: 1, Name: "first"}, {ID: 2, Name: "second updated"}})
+		assert.NoError(t, err)
+		read := readFile(t, fs)
+		assert.Equal(t, "first", read[0].Name)
+		assert.Equal(t, "second updated", read[1].Name)
+
+		// A missing record fails the whole batch
+		_, err = s.UpdateMany([]*testJSONDataInt64{{ID: 1, Name: "failed"}, {ID: 5, Name: "missing"}})
+		var batchErr *BatchError
+		assert.ErrorAs(t, err, &batchErr)
+		assert.ErrorIs(t, err, ErrorBatchFailed)
+		assert.Len(t, batchErr.Errors, 1)
+		assert.ErrorIs(t, batchErr.Errors[1], ErrorDataNotExists)
+
+		data, err := s.ReadOne(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", data.Name)
+
+		// A failed batch leaves the stored record untouched when it is passed
+		stored, err := s.ReadOne(1)
+		assert.NoError(t, err)
+		updatedAt := stored.UpdatedAt
+		_, err = s.UpdateMany([]*testJSONDataInt64{stored, {ID: 5, Name: "missing"}})
+		assert.ErrorIs(t, err, ErrorBatchFailed)
+		assert.Equal(t, updatedAt, stored.UpdatedAt)
+		data, err = s.ReadOne(1)
+		assert.NoError(t, err)
+		assert.Same(t, stored, data)
+	})
+
+	t.Run("DeleteMany", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+		_, err = s.CreateMany([]*testJSONDataInt64{{Name: "second"}, {Name: "third"}})
+		assert.NoError(t, err)
+
+		// A missing record fails the whole batch
+		err = s.DeleteMany([]int64{1, 4, 5})
+		var batchErr *BatchError
+		assert.ErrorAs(t, err, &batchErr)
+		assert.Len(t, batchErr.Errors, 2)
+		assert.Len(t, readFile(t, fs), 3)
+
+		err = s.DeleteMany([]int64{1, 2})
+		assert.NoError(t, err)
+		read := readFile(t, fs)
+		assert.Len(t, read, 1)
+		assert.Equal(t, int64(3), read[0].ID)
+	})
+
+	t.Run("DeleteWhere", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+		_, err = s.CreateMany([]*testJSONDataInt64{{Name: "delete"}, {Name: "delete"}})
+		assert.NoError(t, err)
+
+		deleted, err := s.DeleteWhere(func(d *testJSONDataInt64) bool { return d.Name == "delete" })
+		assert.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+		assert.Len(t, readFile(t, fs), 1)
+	})
+
+	t.Run("SingleWrite", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithJournal(0))
+		assert.NoError(t, err)
+
+		_, err = s.CreateMany([]*testJSONDataInt64{{Name: "second"}, {Name: "third"}, {Name: "fourth"}})
+		assert.NoError(t, err)
+
+		// The batch is a single journal entry
+		journal, err := afero.ReadFile(fs, "./int64.json.wal")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, bytes.Count(journal, []byte("\n")))
+	})
+
+	t.Run("FailedWrite", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](afero.NewReadOnlyFs(fs), "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		data := []*testJSONDataInt64{{Name: "second"}, {Name: "third"}}
+		_, err = s.CreateMany(data)
+		assert.Error(t, err)
+		read, err := s.ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, read, 1)
+
+		// The records passed are only changed once the batch is written
+		for _, d := range data {
+			assert.Zero(t, d.ID)
+			assert.True(t, d.CreatedAt.IsZero())
+		}
+	})
+}
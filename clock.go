@@ -0,0 +1,48 @@
+package gofilestorer
+
+import "time"
+
+// Clock provides the current time for record timestamps
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Set the clock used for record timestamps
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// Convert record timestamps to loc, such as time.UTC
+func WithTimeLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.timeLocation = loc
+	}
+}
+
+// Truncate record timestamps to a multiple of precision, such as time.Second
+func WithTimePrecision(precision time.Duration) Option {
+	return func(o *options) {
+		o.timePrecision = precision
+	}
+}
+
+// get the current time for record timestamps from the clock, converted and
+// truncated as configured
+func (s *storer[K, V]) now() time.Time {
+	now := s.clock.Now()
+	if s.timeLocation != nil {
+		now = now.In(s.timeLocation)
+	}
+	if s.timePrecision > 0 {
+		now = now.Truncate(s.timePrecision)
+	}
+	return now
+}
@@ -0,0 +1,57 @@
+package gofilestorer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterTimestamps(t *testing.T) {
+	loc := time.FixedZone("UTC-8", -8*60*60)
+	clock := &testClock{now: time.Date(2023, 1, 2, 3, 4, 5, 678912345, loc)}
+	opts := []Option{WithClock(clock), WithTimeLocation(time.UTC), WithTimePrecision(time.Millisecond), WithCreateIfMissing()}
+	expected := time.Date(2023, 1, 2, 11, 4, 5, 678000000, time.UTC)
+
+	t.Run("JSON", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, "timestamps.json", func(_ []*testJSONDataUUID, _ *testJSONDataUUID) uuid.UUID {
+			return uuid.New()
+		}, opts...)
+		assert.NoError(t, err)
+
+		// Create
+		data, err := s.Create(&testJSONDataUUID{Name: "new"})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, data.CreatedAt)
+
+		// Update
+		clock.now = clock.now.Add(time.Hour)
+		data, err = s.Update(data.ID, &testJSONDataUUID{Name: "updated"})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, data.CreatedAt)
+		assert.Equal(t, expected.Add(time.Hour), *data.UpdatedAt)
+		clock.now = clock.now.Add(-time.Hour)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		fs := getCSVFilesystem(t)
+		s, err := NewCSVWriter[uuid.UUID, *testCSVData](fs, "timestamps.csv", ',', func(_ []*testCSVData, _ *testCSVData) uuid.UUID {
+			return uuid.New()
+		}, opts...)
+		assert.NoError(t, err)
+
+		data, err := s.Create(&testCSVData{Name: "new"})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, data.CreatedAt)
+
+		// Timestamps are written in the normalized form
+		r, err := NewCSVReader[uuid.UUID, *testCSVData](fs, "timestamps.csv", ',')
+		assert.NoError(t, err)
+		read, err := r.ReadOne(data.ID)
+		assert.NoError(t, err)
+		assert.True(t, expected.Equal(read.CreatedAt))
+		assert.Equal(t, time.UTC, read.CreatedAt.Location())
+	})
+}
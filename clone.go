@@ -0,0 +1,83 @@
+package gofilestorer
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Cloner is implemented by records that can make a deep copy of themselves,
+// which WithDefensiveCopies and Patch use instead of encoding and decoding them
+// in the format of the file
+type Cloner[V any] interface {
+	Clone() V
+}
+
+// Store and return deep copies of records, so that records passed to or read
+// from the storer can be modified without changing the stored records. Records
+// are copied with Clone when V implements Cloner and by encoding and decoding
+// them in the format of the file otherwise, which only copies the fields that
+// are written to the file.
+func WithDefensiveCopies() Option {
+	return func(o *options) {
+		o.defensiveCopies = true
+	}
+}
+
+// copy data when the storer makes defensive copies
+func (s *storer[K, V]) clone(data V) (V, error) {
+	if !s.defensiveCopies {
+		return data, nil
+	}
+	return s.copyRecord(data)
+}
+
+// copy data into a new slice, deep copying the records when the storer makes
+// defensive copies
+func (s *storer[K, V]) cloneAll(data []V) ([]V, error) {
+	clones := make([]V, len(data))
+	for i, record := range data {
+		clone, err := s.clone(record)
+		if err != nil {
+			return nil, err
+		}
+		clones[i] = clone
+	}
+	return clones, nil
+}
+
+// copy data for a change that must leave the stored record untouched, with
+// Clone when V implements Cloner and by encoding and decoding it in the format
+// of the file otherwise, which resets fields that are not written to the file
+func (s *storer[K, V]) copyRecord(data V) (V, error) {
+	if c, ok := any(data).(Cloner[V]); ok {
+		return c.Clone(), nil
+	}
+
+	dataBytes, err := s.encodeRecord(data)
+	if err != nil {
+		return *new(V), fmt.Errorf("error marshaling record copy: %w", err)
+	}
+	clone, err := s.decodeRecord(dataBytes)
+	if err != nil {
+		return *new(V), fmt.Errorf("error unmarshaling record copy: %w", err)
+	}
+	return clone, nil
+}
+
+// report whether a and b point to the same record
+func sameRecord[V any](a, b V) bool {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	return av.Kind() == reflect.Pointer && bv.Kind() == reflect.Pointer && av.Pointer() == bv.Pointer()
+}
+
+// copy the struct data points to, so that fields can be set on the copy
+// without changing data. Records that are not pointers are copied already.
+func shallowCopy[V any](data V) V {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return data
+	}
+	c := reflect.New(v.Type().Elem())
+	c.Elem().Set(v.Elem())
+	return c.Interface().(V)
+}
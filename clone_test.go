@@ -0,0 +1,117 @@
+package gofilestorer
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+type testJSONDataCloner struct {
+	testJSONDataString
+	clones int
+}
+
+func (d *testJSONDataCloner) Clone() *testJSONDataCloner {
+	c := *d
+	c.clones++
+	return &c
+}
+
+func TestWriterDefensiveCopies(t *testing.T) {
+	newIdFunc := func(dataArray []*testJSONDataString, _ *testJSONDataString) string {
+		return "new"
+	}
+
+	t.Run("ReadAllSlice", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[string, *testJSONDataString](fs, "string.json", newIdFunc)
+		assert.NoError(t, err)
+
+		// Without the option, modifying the returned slice does not change the storer
+		read, err := s.ReadAll()
+		assert.NoError(t, err)
+		read[0] = &testJSONDataString{ID: "replaced"}
+
+		read, err = s.ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, "foobar", read[0].ID)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[string, *testJSONDataString](fs, "string.json", newIdFunc, WithDefensiveCopies())
+		assert.NoError(t, err)
+
+		// Create
+		data, err := s.Create(&testJSONDataString{Name: "new"})
+		assert.NoError(t, err)
+		data.Name = "modified"
+
+		// ReadOne
+		read, err := s.ReadOne("new")
+		assert.NoError(t, err)
+		assert.Equal(t, "new", read.Name)
+		read.Name = "modified"
+
+		// ReadAll
+		all, err := s.ReadAll()
+		assert.NoError(t, err)
+		for _, record := range all {
+			assert.NotEqual(t, "modified", record.Name)
+			record.Name = "modified"
+		}
+
+		// Find
+		found, err := s.Find(func(d *testJSONDataString) bool { return d.Name == "new" })
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+		found[0].Name = "modified"
+
+		// Tx
+		tx, err := s.Begin()
+		assert.NoError(t, err)
+		read, err = tx.ReadOne("new")
+		assert.NoError(t, err)
+		read.Name = "modified"
+		err = tx.Rollback()
+		assert.NoError(t, err)
+
+		read, err = s.ReadOne("new")
+		assert.NoError(t, err)
+		assert.Equal(t, "new", read.Name)
+		assert.True(t, data.CreatedAt.Equal(read.CreatedAt))
+	})
+
+	t.Run("FileFormat", func(t *testing.T) {
+		newIdFunc := func(_ []*testYAMLDataSoftDelete, _ *testYAMLDataSoftDelete) uuid.UUID {
+			return uuid.New()
+		}
+		fs := afero.NewMemMapFs()
+		s, err := NewYAMLWriter[uuid.UUID, *testYAMLDataSoftDelete](fs, "./copies.yaml", newIdFunc, WithCreateIfMissing(), WithDefensiveCopies())
+		assert.NoError(t, err)
+
+		// Copies keep the fields that are written to the file, even when they
+		// cannot be written as JSON
+		data, err := s.Create(&testYAMLDataSoftDelete{testYAMLData: testYAMLData{Name: "new"}, Secret: "secret"})
+		assert.NoError(t, err)
+		read, err := s.ReadOne(data.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", read.Secret)
+
+		dataBytes, err := afero.ReadFile(fs, "./copies.yaml")
+		assert.NoError(t, err)
+		assert.Contains(t, string(dataBytes), "secret: secret")
+	})
+
+	t.Run("Cloner", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[string, *testJSONDataCloner](fs, "string.json", nil, WithDefensiveCopies())
+		assert.NoError(t, err)
+
+		read, err := s.ReadOne("foobar")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, read.clones)
+	})
+}
@@ -0,0 +1,45 @@
+package gofilestorer
+
+import "context"
+
+// acquire a lock, giving up when ctx is done. A lock that is acquired after
+// giving up is released in the background.
+func lockContext(ctx context.Context, tryLock func() bool, lock, unlock func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if tryLock() {
+		return nil
+	}
+	if ctx.Done() == nil {
+		lock()
+		return nil
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		lock()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		return nil
+	case <-ctx.Done():
+		go func() {
+			<-locked
+			unlock()
+		}()
+		return ctx.Err()
+	}
+}
+
+// lock the storer mutex for writing, giving up when ctx is done
+func (s *storer[K, V]) lock(ctx context.Context) error {
+	return lockContext(ctx, s.mutex.TryLock, s.mutex.Lock, s.mutex.Unlock)
+}
+
+// lock the storer mutex for reading, giving up when ctx is done
+func (s *storer[K, V]) rlock(ctx context.Context) error {
+	return lockContext(ctx, s.mutex.TryRLock, s.mutex.RLock, s.mutex.RUnlock)
+}
@@ -0,0 +1,85 @@
+package gofilestorer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterContext(t *testing.T) {
+	newIdFunc := func(_ []*testJSONDataUUID, _ *testJSONDataUUID) uuid.UUID {
+		return uuid.New()
+	}
+
+	t.Run("Canceled", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, "uuid.json", newIdFunc)
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = s.ReadAllContext(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = s.FindContext(ctx, func(*testJSONDataUUID) bool { return true })
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = s.CreateContext(ctx, &testJSONDataUUID{Name: "canceled"})
+		assert.ErrorIs(t, err, context.Canceled)
+		err = s.FlushContext(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		// Nothing was created
+		read, err := s.ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, read, 1)
+	})
+
+	t.Run("WaitingForMutex", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, "uuid.json", newIdFunc)
+		assert.NoError(t, err)
+
+		// Hold the lock as a slow write would
+		w := s.(*jsonWriter[uuid.UUID, *testJSONDataUUID])
+		w.mutex.Lock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = s.CreateContext(ctx, &testJSONDataUUID{Name: "timeout"})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		_, err = s.ReadOneContext(ctx, uuid.New())
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// The storer is usable once the lock is released
+		w.mutex.Unlock()
+		_, err = s.Create(&testJSONDataUUID{Name: "new"})
+		assert.NoError(t, err)
+		read, err := s.ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, read, 2)
+	})
+
+	t.Run("WaitingForFileLock", func(t *testing.T) {
+		fs := afero.NewBasePathFs(afero.NewOsFs(), t.TempDir())
+		s, err := NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, "uuid.json", newIdFunc, WithCreateIfMissing(), WithFileLock())
+		assert.NoError(t, err)
+
+		// Hold the file lock as another writer would
+		w := s.(*jsonWriter[uuid.UUID, *testJSONDataUUID])
+		unlock, err := w.lockFile(context.Background())
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = s.CreateContext(ctx, &testJSONDataUUID{Name: "timeout"})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		unlock()
+		_, err = s.Create(&testJSONDataUUID{Name: "new"})
+		assert.NoError(t, err)
+	})
+}
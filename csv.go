@@ -0,0 +1,564 @@
+package gofilestorer
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CSVQuoting controls which fields are quoted when writing CSV files
+type CSVQuoting int
+
+const (
+	// Quote fields containing the separator, quotes, line breaks or
+	// surrounding whitespace
+	QuoteMinimal CSVQuoting = iota
+	// Quote all fields
+	QuoteAll
+)
+
+// LineTerminator ends the lines of CSV files
+type LineTerminator string
+
+const (
+	LF   LineTerminator = "\n"
+	CRLF LineTerminator = "\r\n"
+)
+
+// Set which fields are quoted when writing CSV files
+func WithCSVQuoting(quoting CSVQuoting) Option {
+	return func(o *options) {
+		o.csvQuoting = quoting
+	}
+}
+
+// Set the line terminator used when writing CSV files. Both are accepted when
+// reading.
+func WithCSVLineTerminator(lineTerminator LineTerminator) Option {
+	return func(o *options) {
+		o.csvLineTerminator = lineTerminator
+	}
+}
+
+// Set whether CSV files start with a header. Without a header, fields are
+// read and written in the order of the fields of the record type.
+func WithCSVHeader(header bool) Option {
+	return func(o *options) {
+		o.csvNoHeader = !header
+	}
+}
+
+// csvComment starts comment lines in CSV files
+const csvComment = '#'
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	stringerType        = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// csvMarshaler is implemented by records that convert themselves into a CSV
+// row, in the order of the columns of the record type
+type csvMarshaler interface {
+	MarshalCSV() ([]string, error)
+}
+
+// csvUnmarshaler is implemented by records that set themselves from a CSV row
+// and the header of the file
+type csvUnmarshaler interface {
+	UnmarshalCSV(header, values []string) error
+}
+
+type csvCodec[V any] struct {
+	separator      rune
+	quoting        CSVQuoting
+	lineTerminator LineTerminator
+	header         bool
+}
+
+// csvField is a column of a CSV file and the index of the struct field it is
+// stored in. The field tagged with the any option catches the columns without
+// a field of their own.
+type csvField struct {
+	name     string
+	index    []int
+	catchAll bool
+}
+
+// build the codec for a CSV file with the given separator from the options
+func newCSVCodec[V any](separator rune, o options) csvCodec[V] {
+	lineTerminator := o.csvLineTerminator
+	if lineTerminator == "" {
+		lineTerminator = LF
+	}
+	return csvCodec[V]{
+		separator:      separator,
+		quoting:        o.csvQuoting,
+		lineTerminator: lineTerminator,
+		header:         !o.csvNoHeader,
+	}
+}
+
+// unmarshal the CSV header and rows into records. The fields are split with
+// encoding/csv and matched to the columns of V by the header, or by
+// position without a header. Unknown columns are stored in the catch-all field
+// of V, if any, and ignored otherwise.
+func (c csvCodec[V]) decode(dataBytes []byte) ([]V, error) {
+	rows, err := c.readRows(dataBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, catchAll, err := csvColumns(recordType[V]())
+	if err != nil {
+		return nil, err
+	}
+	header := make([]string, len(fields))
+	columns := make([]*csvField, len(fields))
+	for i := range fields {
+		header[i] = fields[i].name
+		columns[i] = &fields[i]
+	}
+	if c.header {
+		if len(rows) == 0 {
+			return []V{}, nil
+		}
+		byName := map[string]*csvField{}
+		for i := range fields {
+			byName[fields[i].name] = &fields[i]
+		}
+		header = make([]string, len(rows[0]))
+		columns = make([]*csvField, len(rows[0]))
+		for i, name := range rows[0] {
+			header[i] = name
+			columns[i] = byName[header[i]]
+			if columns[i] == nil {
+				columns[i] = catchAll
+			}
+		}
+		rows = rows[1:]
+	}
+
+	data := make([]V, 0, len(rows))
+	for i, row := range rows {
+		if len(row) > len(columns) {
+			return nil, fmt.Errorf("record %d: %w", i+1, csv.ErrFieldCount)
+		}
+
+		record := newRecord[V]()
+		value := reflect.ValueOf(&record).Elem()
+		if value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		if u, ok := value.Addr().Interface().(csvUnmarshaler); ok {
+			if len(row) != len(header) {
+				return nil, fmt.Errorf("record %d: %w", i+1, csv.ErrFieldCount)
+			}
+			if err := u.UnmarshalCSV(header, row); err != nil {
+				return nil, fmt.Errorf("record %d: %w", i+1, err)
+			}
+			data = append(data, record)
+			continue
+		}
+
+		for j, token := range row {
+			if columns[j] == nil {
+				continue
+			}
+			field, _ := fieldByIndex(value, columns[j].index, true)
+			if columns[j].catchAll {
+				err = unmarshalCSVCatchAll(field, header[j], token)
+			} else {
+				err = unmarshalCSVField(field, token)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("record %d: field %s: %w", i+1, header[j], err)
+			}
+		}
+		data = append(data, record)
+	}
+	return data, nil
+}
+
+// split a CSV file into rows of fields with encoding/csv. Surrounding
+// whitespace is trimmed from fields that are not quoted, so that padded files
+// are read as before, while quoted fields are kept as they are.
+func (c csvCodec[V]) readRows(dataBytes []byte) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(dataBytes))
+	reader.Comma = c.separator
+	reader.Comment = csvComment
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+
+	lines := bytes.Split(dataBytes, []byte{'\n'})
+	rows := [][]string{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := range row {
+			line, column := reader.FieldPos(i)
+			if line > len(lines) || column > len(lines[line-1]) || lines[line-1][column-1] != '"' {
+				row[i] = strings.TrimSpace(row[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// marshal the records into a CSV header and rows, deriving the header from
+// V so that it is written even when there are no records. The keys of a
+// catch-all map are written as additional columns when there is a header.
+func (c csvCodec[V]) encode(data []V) ([]byte, error) {
+	fields, catchAll, err := csvColumns(recordType[V]())
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.name
+	}
+	extra := []string{}
+	if c.header && catchAll != nil {
+		extra = catchAllKeys(data, catchAll, header)
+	}
+
+	var buf bytes.Buffer
+	if c.header {
+		c.writeRow(&buf, append(header, extra...))
+	}
+
+	row := make([]string, len(fields)+len(extra))
+	for _, record := range data {
+		value := reflect.ValueOf(&record).Elem()
+		if value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		if m, ok := value.Addr().Interface().(csvMarshaler); ok {
+			marshaled, err := m.MarshalCSV()
+			if err != nil {
+				return nil, err
+			}
+			c.writeRow(&buf, marshaled)
+			continue
+		}
+
+		for i, field := range fields {
+			row[i] = ""
+			if v, ok := fieldByIndex(value, field.index, false); ok {
+				if row[i], err = marshalCSVField(v); err != nil {
+					return nil, fmt.Errorf("field %s: %w", field.name, err)
+				}
+			}
+		}
+		var m reflect.Value
+		if len(extra) > 0 {
+			m, _ = fieldByIndex(value, catchAll.index, false)
+		}
+		for i, key := range extra {
+			row[len(fields)+i] = ""
+			if !m.IsValid() {
+				continue
+			}
+			if v := m.MapIndex(reflect.ValueOf(key).Convert(m.Type().Key())); v.IsValid() {
+				if row[len(fields)+i], err = marshalCSVField(v); err != nil {
+					return nil, fmt.Errorf("field %s: %w", key, err)
+				}
+			}
+		}
+		c.writeRow(&buf, row)
+	}
+	return buf.Bytes(), nil
+}
+
+// collect the sorted keys of the catch-all maps of the records that are not
+// columns of their own
+func catchAllKeys[V any](data []V, catchAll *csvField, columns []string) []string {
+	seen := map[string]bool{}
+	for _, column := range columns {
+		seen[column] = true
+	}
+
+	keys := []string{}
+	for _, record := range data {
+		value := reflect.ValueOf(&record).Elem()
+		if value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		m, ok := fieldByIndex(value, catchAll.index, false)
+		if !ok || m.Kind() != reflect.Map {
+			continue
+		}
+		for _, key := range m.MapKeys() {
+			if !seen[key.String()] {
+				seen[key.String()] = true
+				keys = append(keys, key.String())
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// write a row of fields, quoting them according to the codec. Quoted fields
+// are escaped the way encoding/csv reads them.
+func (c csvCodec[V]) writeRow(buf *bytes.Buffer, fields []string) {
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteRune(c.separator)
+		}
+		if c.quoting == QuoteAll || c.needsQuotes(field) {
+			buf.WriteByte('"')
+			buf.WriteString(strings.ReplaceAll(field, `"`, `""`))
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(field)
+		}
+	}
+	buf.WriteString(string(c.lineTerminator))
+}
+
+// report whether a field has to be quoted to be read back unchanged
+func (c csvCodec[V]) needsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if strings.ContainsRune(field, c.separator) || strings.ContainsAny(field, "\"\r\n") || strings.HasPrefix(field, string(csvComment)) {
+		return true
+	}
+	r := []rune(field)
+	return unicode.IsSpace(r[0]) || unicode.IsSpace(r[len(r)-1])
+}
+
+// the struct type of the records of V
+func recordType[V any]() reflect.Type {
+	t := reflect.TypeOf((*V)(nil)).Elem()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// list the columns of a struct type from the csv tags of its exported
+// fields, defaulting to the field name. Fields tagged "-" are skipped and the
+// fields of embedded structs are included.
+func csvFields(t reflect.Type) ([]csvField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %s is not a struct", t)
+	}
+
+	fields := []csvField{}
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("csv")
+		if (!f.IsExported() && (!f.Anonymous || f.Type.Kind() == reflect.Pointer)) || tag == "-" {
+			continue
+		}
+
+		embedded := f.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if f.Anonymous && tag == "" && embedded.Kind() == reflect.Struct && !embedded.Implements(textMarshalerType) && !reflect.PointerTo(embedded).Implements(textMarshalerType) {
+			inner, err := csvFields(embedded)
+			if err != nil {
+				return nil, err
+			}
+			for _, field := range inner {
+				if names[field.name] {
+					return nil, fmt.Errorf("type %s has more than one field named %s", t, field.name)
+				}
+				names[field.name] = true
+				fields = append(fields, csvField{name: field.name, index: append([]int{i}, field.index...), catchAll: field.catchAll})
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		if names[name] {
+			return nil, fmt.Errorf("type %s has more than one field named %s", t, name)
+		}
+		names[name] = true
+		catchAll := false
+		for _, flag := range strings.Split(flags, ",") {
+			catchAll = catchAll || flag == "any"
+		}
+		fields = append(fields, csvField{name: name, index: f.Index, catchAll: catchAll})
+	}
+	return fields, nil
+}
+
+// split the fields of a struct type into its columns and its catch-all
+// field, which is the last field tagged with the any option
+func csvColumns(t reflect.Type) ([]csvField, *csvField, error) {
+	fields, err := csvFields(t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := []csvField{}
+	var catchAll *csvField
+	for i := range fields {
+		if fields[i].catchAll {
+			catchAll = &fields[i]
+			continue
+		}
+		columns = append(columns, fields[i])
+	}
+	if catchAll != nil {
+		if f := t.FieldByIndex(catchAll.index); f.Type.Kind() == reflect.Map && f.Type.Key().Kind() != reflect.String {
+			return nil, nil, fmt.Errorf("type %s has a catch-all map %s without string keys", t, f.Name)
+		}
+	}
+	return columns, catchAll, nil
+}
+
+// get the field at index, allocating nil embedded structs on the way when
+// alloc is set and reporting false otherwise
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// convert a field into its CSV text. Nil pointers are empty.
+func marshalCSVField(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		text, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if v.Type().Implements(stringerType) {
+		return v.Interface().(fmt.Stringer).String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return hex.EncodeToString(v.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// set a field from its CSV text. Empty text leaves the field at its zero
+// value, so that nil pointers are read back as nil.
+func unmarshalCSVField(v reflect.Value, text string) error {
+	if text == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		v.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		v.SetInt(i)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		v.SetUint(i)
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, v.Type().Bits())
+		v.SetFloat(f)
+		return err
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// Bytes that are not hex encoded are taken as they are
+			b, err := hex.DecodeString(text)
+			if err != nil {
+				b = []byte(text)
+			}
+			v.SetBytes(b)
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// store the text of a column without a field of its own in the catch-all
+// field v, which is either a map from column names to values or a single
+// value. Empty text is skipped.
+func unmarshalCSVCatchAll(v reflect.Value, name, text string) error {
+	if text == "" {
+		return nil
+	}
+	if v.Kind() != reflect.Map {
+		return unmarshalCSVField(v, text)
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	elem := reflect.New(v.Type().Elem()).Elem()
+	if err := unmarshalCSVField(elem, text); err != nil {
+		return err
+	}
+	v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+	return nil
+}
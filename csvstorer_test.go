@@ -1,6 +1,7 @@
 package gofilestorer
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -155,3 +156,249 @@ func TestCSVWriter(t *testing.T) {
 	err = s.Delete(data.ID)
 	assert.Error(t, err)
 }
+
+func TestCSVWriterCreateIfMissing(t *testing.T) {
+	fs := getCSVFilesystem(t)
+
+	newIdFunc := func(dataArray []*testCSVData, data *testCSVData) uuid.UUID {
+		return uuid.New()
+	}
+
+	// Create missing file and parent directories
+	s, err := NewCSVWriter[uuid.UUID, *testCSVData](fs, "./missing/dir/data.csv", ',', newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	dataBytes, err := afero.ReadFile(fs, "./missing/dir/data.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, "id,created_at,updated_at,name\n", string(dataBytes))
+
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 0)
+
+	// Create
+	data := &testCSVData{Name: "new"}
+	_, err = s.Create(data)
+	assert.NoError(t, err)
+
+	// Existing file is left untouched
+	s, err = NewCSVWriter[uuid.UUID, *testCSVData](fs, "./missing/dir/data.csv", ',', newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	assert.Equal(t, "new", read[0].Name)
+
+	// Delete the last record keeps the header
+	err = s.Delete(data.ID)
+	assert.NoError(t, err)
+
+	dataBytes, err = afero.ReadFile(fs, "./missing/dir/data.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, "id,created_at,updated_at,name\n", string(dataBytes))
+}
+
+func TestCSVWriterUpdate(t *testing.T) {
+	fs := getCSVFilesystem(t)
+
+	newIdFunc := func(dataArray []*testCSVData, data *testCSVData) uuid.UUID {
+		return uuid.New()
+	}
+
+	s, err := NewCSVWriter[uuid.UUID, *testCSVData](fs, "./update.csv", ',', newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+
+	data := &testCSVData{Name: "new"}
+	_, err = s.Create(data)
+	assert.NoError(t, err)
+
+	// Update with a new struct
+	_, err = s.Update(data.ID, &testCSVData{ID: data.ID, Name: "updated"})
+	assert.NoError(t, err)
+
+	s, err = NewCSVWriter[uuid.UUID, *testCSVData](fs, "./update.csv", ',', newIdFunc)
+	assert.NoError(t, err)
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	assert.Equal(t, "updated", read[0].Name)
+	assert.True(t, data.CreatedAt.Equal(read[0].CreatedAt))
+	assert.NotNil(t, read[0].UpdatedAt)
+}
+
+func TestCSVWriterRoundTrip(t *testing.T) {
+	newIdFunc := func(dataArray []*testCSVData, data *testCSVData) uuid.UUID {
+		return uuid.New()
+	}
+	names := []string{"Foobar", "semi;colon", "com,ma", `say "hi"`, "two words", `"`, "#hash", "", " padded ", "\ttab", "line\nbreak", "unit\x1fseparator", "bell\a"}
+
+	for name, tc := range map[string]struct {
+		opts     []Option
+		expected string
+	}{
+		"Default":    {expected: "id;created_at;updated_at;name\n"},
+		"QuoteAll":   {opts: []Option{WithCSVQuoting(QuoteAll)}, expected: `"id";"created_at";"updated_at";"name"` + "\n"},
+		"CRLF":       {opts: []Option{WithCSVLineTerminator(CRLF)}, expected: "id;created_at;updated_at;name\r\n"},
+		"HeaderLess": {opts: []Option{WithCSVHeader(false)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs := getCSVFilesystem(t)
+			opts := append([]Option{WithCreateIfMissing()}, tc.opts...)
+
+			// Create
+			s, err := NewCSVWriter[uuid.UUID, *testCSVData](fs, "./roundtrip.csv", ';', newIdFunc, opts...)
+			assert.NoError(t, err)
+			for _, name := range names {
+				_, err = s.Create(&testCSVData{Name: name})
+				assert.NoError(t, err)
+			}
+
+			// The file uses the configured separator and format
+			dataBytes, err := afero.ReadFile(fs, "./roundtrip.csv")
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(dataBytes), tc.expected))
+			if tc.expected == "" {
+				assert.False(t, strings.HasPrefix(string(dataBytes), "id"))
+			}
+
+			// Reopen
+			r, err := NewCSVReader[uuid.UUID, *testCSVData](fs, "./roundtrip.csv", ';', tc.opts...)
+			assert.NoError(t, err)
+			read, err := r.ReadAll()
+			assert.NoError(t, err)
+			assert.Len(t, read, len(names))
+			for i, name := range names {
+				assert.Equal(t, name, read[i].Name)
+			}
+
+			written, err := s.ReadAll()
+			assert.NoError(t, err)
+			for i := range written {
+				assert.Equal(t, written[i].ID, read[i].ID)
+				assert.True(t, written[i].CreatedAt.Equal(read[i].CreatedAt))
+			}
+		})
+	}
+}
+
+// testCSVLevel is written with String and read with UnmarshalText
+type testCSVLevel int
+
+func (l testCSVLevel) String() string {
+	return [...]string{"low", "high"}[l]
+}
+
+func (l *testCSVLevel) UnmarshalText(text []byte) error {
+	if string(text) == "high" {
+		*l = 1
+	}
+	return nil
+}
+
+// testCSVDataExtra keeps the columns without a field of their own
+type testCSVDataExtra struct {
+	testCSVData
+	Level testCSVLevel      `csv:"level"`
+	Extra map[string]string `csv:",any"`
+}
+
+// testCSVDataRow converts itself to and from CSV rows
+type testCSVDataRow struct {
+	testCSVData
+}
+
+func (d *testCSVDataRow) MarshalCSV() ([]string, error) {
+	return []string{d.ID.String(), d.CreatedAt.Format(time.RFC3339Nano), "", strings.ToUpper(d.Name)}, nil
+}
+
+func (d *testCSVDataRow) UnmarshalCSV(header, values []string) error {
+	for i, column := range header {
+		switch column {
+		case "id":
+			d.ID = uuid.MustParse(values[i])
+		case "name":
+			d.Name = strings.ToLower(values[i])
+		}
+	}
+	return nil
+}
+
+func TestCSVReaderCompatibility(t *testing.T) {
+	newIdFunc := func(dataArray []*testCSVDataExtra, data *testCSVDataExtra) uuid.UUID {
+		return uuid.New()
+	}
+
+	t.Run("Whitespace", func(t *testing.T) {
+		// Unquoted fields and headers are trimmed, quoted fields are kept
+		fs := afero.NewMemMapFs()
+		err := afero.WriteFile(fs, "padded.csv", []byte(` id ; name ; level
+ e21ab9b3-bb4e-4921-815b-41de7980c5da ;  Foobar  ; high
+b5a3e1f2-60b4-4bbf-a3e5-3d2ad0d2a0f1;" quoted ";low
+`), 0644)
+		assert.NoError(t, err)
+
+		r, err := NewCSVReader[uuid.UUID, *testCSVDataExtra](fs, "./padded.csv", ';')
+		assert.NoError(t, err)
+		read, err := r.ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, read, 2)
+		assert.Equal(t, uuid.MustParse("e21ab9b3-bb4e-4921-815b-41de7980c5da"), read[0].ID)
+		assert.Equal(t, "Foobar", read[0].Name)
+		assert.Equal(t, testCSVLevel(1), read[0].Level)
+		assert.Equal(t, " quoted ", read[1].Name)
+	})
+
+	t.Run("CatchAll", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		err := afero.WriteFile(fs, "extra.csv", []byte(`id;name;color;size
+e21ab9b3-bb4e-4921-815b-41de7980c5da;Foobar;red;
+`), 0644)
+		assert.NoError(t, err)
+
+		// Unknown columns are read into the catch-all map
+		s, err := NewCSVWriter[uuid.UUID, *testCSVDataExtra](fs, "./extra.csv", ';', newIdFunc)
+		assert.NoError(t, err)
+		read, err := s.ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"color": "red"}, read[0].Extra)
+
+		// And written back as columns, with Stringer fields as their strings
+		_, err = s.Create(&testCSVDataExtra{testCSVData: testCSVData{Name: "new"}, Level: 1, Extra: map[string]string{"size": "L"}})
+		assert.NoError(t, err)
+		dataBytes, err := afero.ReadFile(fs, "./extra.csv")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(dataBytes), "id;created_at;updated_at;name;level;color;size\n"))
+		assert.Contains(t, string(dataBytes), ";new;high;;L\n")
+
+		r, err := NewCSVReader[uuid.UUID, *testCSVDataExtra](fs, "./extra.csv", ';')
+		assert.NoError(t, err)
+		read, err = r.ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, read, 2)
+		assert.Equal(t, map[string]string{"color": "red"}, read[0].Extra)
+		assert.Equal(t, map[string]string{"size": "L"}, read[1].Extra)
+		assert.Equal(t, testCSVLevel(1), read[1].Level)
+	})
+
+	t.Run("Hooks", func(t *testing.T) {
+		// MarshalCSV and UnmarshalCSV convert whole rows
+		fs := afero.NewMemMapFs()
+		s, err := NewCSVWriter[uuid.UUID, *testCSVDataRow](fs, "./rows.csv", ';', func(dataArray []*testCSVDataRow, data *testCSVDataRow) uuid.UUID {
+			return uuid.New()
+		}, WithCreateIfMissing())
+		assert.NoError(t, err)
+		data, err := s.Create(&testCSVDataRow{testCSVData: testCSVData{Name: "new"}})
+		assert.NoError(t, err)
+
+		dataBytes, err := afero.ReadFile(fs, "./rows.csv")
+		assert.NoError(t, err)
+		assert.Contains(t, string(dataBytes), ";NEW\n")
+
+		r, err := NewCSVReader[uuid.UUID, *testCSVDataRow](fs, "./rows.csv", ';')
+		assert.NoError(t, err)
+		read, err := r.ReadOne(data.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", read.Name)
+	})
+}
@@ -3,5 +3,20 @@ package gofilestorer
 import "errors"
 
 var (
-	ErrorDataNotExists = errors.New("data not exists")
+	ErrorDataNotExists      = errors.New("data not exists")
+	ErrorDataExists         = errors.New("data exists")
+	ErrorDuplicateID        = errors.New("duplicate id")
+	ErrorIDMismatch         = errors.New("id mismatch")
+	ErrorInvalidOption      = errors.New("invalid option")
+	ErrorClosed             = errors.New("storer closed")
+	ErrorTxDone             = errors.New("transaction already committed or rolled back")
+	ErrorIndexNotExists     = errors.New("index not exists")
+	ErrorIndexConflict      = errors.New("unique index conflict")
+	ErrorNotVersioned       = errors.New("data not versioned")
+	ErrorVersionConflict    = errors.New("version conflict")
+	ErrorTxConflict         = errors.New("transaction conflicts with a concurrent change")
+	ErrorBatchFailed        = errors.New("batch failed")
+	ErrorSoftDeleteDisabled = errors.New("soft delete not enabled")
+	ErrorNotDeleted         = errors.New("data not deleted")
+	ErrorDataDeleted        = errors.New("data deleted")
 )
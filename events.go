@@ -0,0 +1,223 @@
+package gofilestorer
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventType describes the kind of change an Event reports
+type EventType string
+
+const (
+	EventCreated  EventType = "created"
+	EventUpdated  EventType = "updated"
+	EventDeleted  EventType = "deleted"
+	EventRestored EventType = "restored"
+	EventPurged   EventType = "purged"
+)
+
+// Event reports a change to a record after it has been written to disk. Old
+// is empty for created records and New is empty for deleted records, unless
+// they were soft deleted.
+type Event[K comparable, V any] struct {
+	Type EventType
+	ID   K
+	Old  V
+	New  V
+}
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(*subscribeOptions)
+
+type subscribeOptions struct {
+	buffer int
+	drop   bool
+}
+
+// Buffer up to n events for the subscriber
+func WithBuffer(n int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.buffer = n
+	}
+}
+
+// Drop events instead of waiting when the buffer of the subscriber is full,
+// so that a slow subscriber cannot stall changes to the writer
+func WithDropWhenFull() SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.drop = true
+	}
+}
+
+// subscription delivers events to a single subscriber
+type subscription[K comparable, V any] struct {
+	events chan Event[K, V]
+	done   chan struct{}
+	once   sync.Once
+	drop   bool
+}
+
+// subscribers holds the subscriptions of a storer. The mutex is held while
+// events are delivered, in the order of the tickets handed out under the
+// storer mutex.
+type subscribers[K comparable, V any] struct {
+	mutex   sync.Mutex
+	turn    sync.Cond
+	subs    []*subscription[K, V]
+	count   atomic.Int32
+	tickets uint64
+	next    uint64
+}
+
+// receive events for every change written to disk on the returned channel
+// until the returned function is called or the writer is closed, which both
+// close the channel. Events are delivered in order after the writer is
+// unlocked, so subscribers may read from the writer. Without WithDropWhenFull
+// changes wait for subscribers with a full buffer, so they must not change
+// the writer from the goroutine receiving events.
+func (s *fileWriter[K, V]) Subscribe(opts ...SubscribeOption) (<-chan Event[K, V], func()) {
+	o := subscribeOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	sub := &subscription[K, V]{
+		events: make(chan Event[K, V], o.buffer),
+		done:   make(chan struct{}),
+		drop:   o.drop,
+	}
+
+	s.mutex.Lock()
+	closed := s.closed
+	if !closed {
+		s.subscribers.mutex.Lock()
+		s.subscribers.subs = append(s.subscribers.subs, sub)
+		s.subscribers.count.Add(1)
+		s.subscribers.mutex.Unlock()
+	}
+	s.mutex.Unlock()
+	if closed {
+		close(sub.events)
+	}
+
+	return sub.events, func() { s.unsubscribe(sub) }
+}
+
+// call handler for every change written to disk, like Subscribe, from a
+// goroutine owned by the subscription
+func (s *fileWriter[K, V]) SubscribeFunc(handler func(Event[K, V]), opts ...SubscribeOption) func() {
+	events, unsubscribe := s.Subscribe(opts...)
+	go func() {
+		for event := range events {
+			handler(event)
+		}
+	}()
+	return unsubscribe
+}
+
+// stop delivering events to sub and close its channel
+func (s *storer[K, V]) unsubscribe(sub *subscription[K, V]) {
+	sub.once.Do(func() {
+		// Release a delivery blocked on this subscriber before waiting for it
+		close(sub.done)
+
+		s.subscribers.mutex.Lock()
+		defer s.subscribers.mutex.Unlock()
+		for i, other := range s.subscribers.subs {
+			if other == sub {
+				s.subscribers.subs = append(s.subscribers.subs[:i:i], s.subscribers.subs[i+1:]...)
+				s.subscribers.count.Add(-1)
+				break
+			}
+		}
+		close(sub.events)
+	})
+}
+
+// queue events for the changes that were written to disk. The storer mutex
+// must be held.
+func (s *storer[K, V]) notify(changes []change[K, V]) {
+	if s.subscribers.count.Load() == 0 {
+		return
+	}
+
+	for _, c := range changes {
+		event := Event[K, V]{ID: c.id}
+		var err error
+		if event.Old, err = s.clone(c.old); err == nil {
+			event.New, err = s.clone(c.new)
+		}
+		if err != nil {
+			s.logger.Printf("error copying event for %v: %v", c.id, err)
+			continue
+		}
+		switch {
+		case c.event != "":
+			event.Type = c.event
+		case c.op == opCreate:
+			event.Type = EventCreated
+		case c.op == opUpdate:
+			event.Type = EventUpdated
+		case c.op == opDelete:
+			event.Type = EventDeleted
+		}
+		s.outbox = append(s.outbox, event)
+	}
+}
+
+// unlock the storer mutex and deliver the queued events. A ticket is taken
+// before the storer is unlocked so that events of concurrent changes are
+// delivered in the order they were written, while delivery never holds the
+// storer mutex.
+func (s *storer[K, V]) unlock() {
+	if len(s.outbox) == 0 {
+		s.mutex.Unlock()
+		return
+	}
+
+	events := s.outbox
+	s.outbox = nil
+	ticket := s.subscribers.tickets
+	s.subscribers.tickets++
+	s.mutex.Unlock()
+
+	s.subscribers.mutex.Lock()
+	defer s.subscribers.mutex.Unlock()
+	if s.subscribers.turn.L == nil {
+		s.subscribers.turn.L = &s.subscribers.mutex
+	}
+	for s.subscribers.next != ticket {
+		s.subscribers.turn.Wait()
+	}
+	defer func() {
+		s.subscribers.next++
+		s.subscribers.turn.Broadcast()
+	}()
+
+	for _, sub := range s.subscribers.subs {
+		for _, event := range events {
+			if sub.drop {
+				select {
+				case sub.events <- event:
+				default:
+				}
+				continue
+			}
+			select {
+			case sub.events <- event:
+			case <-sub.done:
+			}
+		}
+	}
+}
+
+// close the channels of all subscriptions
+func (s *storer[K, V]) closeSubscriptions() {
+	s.subscribers.mutex.Lock()
+	subs := s.subscribers.subs
+	s.subscribers.mutex.Unlock()
+
+	for _, sub := range subs {
+		s.unsubscribe(sub)
+	}
+}
@@ -0,0 +1,193 @@
+package gofilestorer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterSubscribe(t *testing.T) {
+	newIdFunc := func(dataArray []*testJSONDataInt64, _ *testJSONDataInt64) int64 {
+		return int64(len(dataArray) + 1)
+	}
+
+	t.Run("Channel", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		events, unsubscribe := s.Subscribe(WithBuffer(10))
+		defer unsubscribe()
+
+		// Create
+		data, err := s.Create(&testJSONDataInt64{Name: "new"})
+		assert.NoError(t, err)
+		event := <-events
+		assert.Equal(t, EventCreated, event.Type)
+		assert.Equal(t, data.ID, event.ID)
+		assert.Nil(t, event.Old)
+		assert.Equal(t, data, event.New)
+
+		// Update
+		updated, err := s.Update(data.ID, &testJSONDataInt64{Name: "updated"})
+		assert.NoError(t, err)
+		event = <-events
+		assert.Equal(t, EventUpdated, event.Type)
+		assert.Equal(t, data, event.Old)
+		assert.Equal(t, updated, event.New)
+
+		// Delete
+		err = s.Delete(data.ID)
+		assert.NoError(t, err)
+		event = <-events
+		assert.Equal(t, EventDeleted, event.Type)
+		assert.Equal(t, updated, event.Old)
+		assert.Nil(t, event.New)
+	})
+
+	t.Run("AfterFlush", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithFlushPolicy(FlushManual()))
+		assert.NoError(t, err)
+
+		events, unsubscribe := s.Subscribe(WithBuffer(10))
+		defer unsubscribe()
+
+		_, err = s.Create(&testJSONDataInt64{Name: "new"})
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+
+		err = s.Flush()
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("FailedWrite", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](afero.NewReadOnlyFs(fs), "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		events, unsubscribe := s.Subscribe(WithBuffer(10))
+		defer unsubscribe()
+
+		_, err = s.Create(&testJSONDataInt64{Name: "new"})
+		assert.Error(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("DropWhenFull", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		// A subscriber that never reads does not stall changes
+		events, unsubscribe := s.Subscribe(WithBuffer(1), WithDropWhenFull())
+		defer unsubscribe()
+
+		for i := 0; i < 3; i++ {
+			_, err = s.Create(&testJSONDataInt64{Name: "new"})
+			assert.NoError(t, err)
+		}
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("Func", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		received := make(chan Event[int64, *testJSONDataInt64], 10)
+		unsubscribe := s.SubscribeFunc(func(event Event[int64, *testJSONDataInt64]) {
+			// Subscribers may read from the writer
+			_, err := s.ReadOne(event.ID)
+			assert.NoError(t, err)
+			received <- event
+		})
+		defer unsubscribe()
+
+		data, err := s.Create(&testJSONDataInt64{Name: "new"})
+		assert.NoError(t, err)
+		select {
+		case event := <-received:
+			assert.Equal(t, data.ID, event.ID)
+		case <-time.After(time.Second):
+			t.Fatal("event was not delivered")
+		}
+	})
+
+	t.Run("ConcurrentRead", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		// A subscriber reading from the writer while another change waits to
+		// deliver its events does not block either of them
+		var once sync.Once
+		created := make(chan error, 1)
+		unsubscribe := s.SubscribeFunc(func(event Event[int64, *testJSONDataInt64]) {
+			once.Do(func() {
+				go func() {
+					_, err := s.Create(&testJSONDataInt64{Name: "concurrent"})
+					created <- err
+				}()
+				time.Sleep(50 * time.Millisecond)
+			})
+			_, err := s.ReadOne(event.ID)
+			assert.NoError(t, err)
+			_, err = s.ReadAll()
+			assert.NoError(t, err)
+		})
+		defer unsubscribe()
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := s.CreateMany([]*testJSONDataInt64{{Name: "first"}, {Name: "second"}})
+			done <- err
+		}()
+
+		for _, ch := range []chan error{done, created} {
+			select {
+			case err := <-ch:
+				assert.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("changes were blocked by the subscriber")
+			}
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		events, unsubscribe := s.Subscribe()
+		err = s.Close()
+		assert.NoError(t, err)
+		_, ok := <-events
+		assert.False(t, ok)
+
+		// Unsubscribing after close and subscribing to a closed writer are safe
+		unsubscribe()
+		events, _ = s.Subscribe()
+		_, ok = <-events
+		assert.False(t, ok)
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+		assert.NoError(t, err)
+
+		events, unsubscribe := s.Subscribe()
+		unsubscribe()
+		unsubscribe()
+		_, ok := <-events
+		assert.False(t, ok)
+
+		_, err = s.Create(&testJSONDataInt64{Name: "new"})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,148 @@
+package gofilestorer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// write data to a temporary file next to fileName, sync it and rename it over
+// fileName so that readers only ever see the old or the new contents. The
+// mode of an existing file is kept, and perm is only used for new files.
+func writeFileAtomic(fs afero.Fs, fileName string, data []byte, perm os.FileMode) (err error) {
+	if info, statErr := fs.Stat(fileName); statErr == nil {
+		perm = info.Mode().Perm()
+	}
+
+	dir, base := filepath.Split(fileName)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := afero.TempFile(fs, dir, "."+base+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// Leave the original file untouched on any failure
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = fs.Remove(tmpName)
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("error writing temporary file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("error syncing temporary file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("error closing temporary file: %w", err)
+	}
+	if err = fs.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("error setting file mode: %w", err)
+	}
+	if err = fs.Rename(tmpName, fileName); err != nil {
+		return fmt.Errorf("error renaming temporary file: %w", err)
+	}
+
+	return nil
+}
+
+// create fileName and any missing parent directories using create when the
+// file does not exist yet
+func createFileIfMissing(fs afero.Fs, fileName string, create func() error) error {
+	exists, err := afero.Exists(fs, fileName)
+	if err != nil {
+		return fmt.Errorf("error checking file: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	if err := fs.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+
+	return create()
+}
+
+// append data to the end of fileName, reporting whether it was appended. The
+// file is truncated back to its previous size when the write fails, so that
+// it never ends with a partial write. When the file does not end with a
+// newline, a new line is started if complete accepts the last line, and
+// nothing is appended otherwise, since the last line was left behind by a
+// failed write and the file has to be rewritten without it.
+func appendFile(fs afero.Fs, fileName string, data []byte, perm os.FileMode, complete func(line []byte) bool) (bool, error) {
+	f, err := fs.OpenFile(fileName, os.O_RDWR|os.O_CREATE, perm)
+	if err != nil {
+		return false, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, fmt.Errorf("error reading file info: %w", err)
+	}
+	size := info.Size()
+	if size > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, size-1); err != nil {
+			return false, fmt.Errorf("error reading file: %w", err)
+		}
+		if last[0] != '\n' {
+			line, err := lastLine(f, size)
+			if err != nil {
+				return false, fmt.Errorf("error reading file: %w", err)
+			}
+			if !complete(line) {
+				return false, nil
+			}
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		return false, fmt.Errorf("error seeking file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Truncate(size)
+		return false, fmt.Errorf("error appending to file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Truncate(size)
+		return false, fmt.Errorf("error syncing file: %w", err)
+	}
+
+	return true, nil
+}
+
+// read the last line of f, which is size bytes long, by reading backwards
+// from the end until the previous newline
+func lastLine(f io.ReaderAt, size int64) ([]byte, error) {
+	var line []byte
+	chunk := make([]byte, 4096)
+	for end := size; end > 0; {
+		start := end - int64(len(chunk))
+		if start < 0 {
+			start = 0
+		}
+		buf := chunk[:end-start]
+		if _, err := f.ReadAt(buf, start); err != nil {
+			return nil, err
+		}
+		if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+			return append(append([]byte(nil), buf[i+1:]...), line...), nil
+		}
+		line = append(append([]byte(nil), buf...), line...)
+		end = start
+	}
+	return line, nil
+}
@@ -0,0 +1,178 @@
+package gofilestorer
+
+import (
+	"context"
+	"time"
+)
+
+// FlushPolicy controls when the changes made through a writer are written to
+// disk
+type FlushPolicy struct {
+	every    int
+	interval time.Duration
+	manual   bool
+}
+
+// Write every change to disk before returning, which is the default
+func FlushImmediate() FlushPolicy {
+	return FlushPolicy{}
+}
+
+// Write changes to disk once n changes are pending
+func FlushEvery(n int) FlushPolicy {
+	return FlushPolicy{every: n}
+}
+
+// Write pending changes to disk from a background goroutine every interval
+func FlushInterval(interval time.Duration) FlushPolicy {
+	return FlushPolicy{interval: interval}
+}
+
+// Only write changes to disk when Flush or Close is called
+func FlushManual() FlushPolicy {
+	return FlushPolicy{manual: true}
+}
+
+// report whether the pending changes have to be written now
+func (p FlushPolicy) due(pending int) bool {
+	switch {
+	case p.manual, p.interval > 0:
+		return false
+	case p.every > 0:
+		return pending >= p.every
+	default:
+		return true
+	}
+}
+
+// write the pending changes to disk, keeping them pending on failure so that
+// they are retried by the next flush
+func (s *storer[K, V]) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	if s.journal {
+		if err := s.appendJournal(s.pending); err != nil {
+			return err
+		}
+	} else if s.journalSize > 0 {
+		// A journal left by a writer using WithJournal is compacted into the
+		// file, so that it is not replayed over newer changes
+		if err := s.compact(); err != nil {
+			return err
+		}
+	} else if appended, err := s.appendFile(); err != nil {
+		return err
+	} else if !appended {
+		if err := s.writeFile(); err != nil {
+			return err
+		}
+	}
+	s.notify(s.pending)
+	s.pending = nil
+
+	// The changes are durable once they are in the journal, so a failed
+	// compaction is retried on the next flush instead of being returned
+	if s.journal && s.journalEntries >= s.compactAfter {
+		if err := s.compact(); err != nil {
+			s.logger.Printf("error compacting journal %s: %v", s.journalFileName(), err)
+		}
+	}
+
+	return nil
+}
+
+// flush while holding the file lock. The storer mutex must be held.
+func (s *storer[K, V]) lockedFlush(ctx context.Context) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	unlock, err := s.lockFileForWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.flush()
+}
+
+// start flushing pending changes in the background when the flush policy
+// asks for it
+func (s *storer[K, V]) startFlushLoop() {
+	if s.flushPolicy.interval <= 0 {
+		return
+	}
+
+	s.stopFlush = make(chan struct{})
+	s.flushDone = make(chan struct{})
+	go func() {
+		defer close(s.flushDone)
+
+		ticker := time.NewTicker(s.flushPolicy.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.mutex.Lock()
+				if err := s.lockedFlush(context.Background()); err != nil {
+					s.logger.Printf("error flushing %s: %v", s.fileName, err)
+					s.flushErr = err
+				}
+				s.unlock()
+			case <-s.stopFlush:
+				return
+			}
+		}
+	}()
+}
+
+// write pending changes to disk, returning the error of an earlier background
+// flush if there was one
+func (s *storer[K, V]) Flush() error {
+	return s.FlushContext(context.Background())
+}
+
+// write pending changes to disk like Flush, giving up when ctx is done before
+// the storer and the file can be locked
+func (s *storer[K, V]) FlushContext(ctx context.Context) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.unlock()
+
+	if err := s.lockedFlush(ctx); err != nil {
+		return err
+	}
+
+	err := s.flushErr
+	s.flushErr = nil
+	return err
+}
+
+// stop background flushing and watching, write pending changes to disk and
+// close the channels of subscriptions. The storer can still be read after it
+// is closed, but changes are rejected.
+func (s *storer[K, V]) Close() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mutex.Unlock()
+
+	if s.stopFlush != nil {
+		close(s.stopFlush)
+		<-s.flushDone
+	}
+	if s.stopWatch != nil {
+		close(s.stopWatch)
+		<-s.watchDone
+	}
+
+	err := s.Flush()
+	s.closeSubscriptions()
+	return err
+}
@@ -0,0 +1,107 @@
+package gofilestorer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterFlushPolicy(t *testing.T) {
+	newIdFunc := func(dataArray []*testJSONDataInt64, _ *testJSONDataInt64) int64 {
+		return int64(len(dataArray) + 1)
+	}
+	readFile := func(t *testing.T, fs afero.Fs) []*testJSONDataInt64 {
+		r, err := NewJSONReader[int64, *testJSONDataInt64](fs, "./int64.json")
+		assert.NoError(t, err)
+		read, err := r.ReadAll()
+		assert.NoError(t, err)
+		return read
+	}
+
+	t.Run("Manual", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithFlushPolicy(FlushManual()))
+		assert.NoError(t, err)
+
+		_, err = s.Create(&testJSONDataInt64{Name: "new"})
+		assert.NoError(t, err)
+		assert.Len(t, readFile(t, fs), 1)
+
+		// Reads see unflushed changes
+		read, err := s.ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, read, 2)
+
+		err = s.Flush()
+		assert.NoError(t, err)
+		assert.Len(t, readFile(t, fs), 2)
+	})
+
+	t.Run("Every", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithFlushPolicy(FlushEvery(2)))
+		assert.NoError(t, err)
+
+		_, err = s.Create(&testJSONDataInt64{Name: "second"})
+		assert.NoError(t, err)
+		assert.Len(t, readFile(t, fs), 1)
+
+		_, err = s.Create(&testJSONDataInt64{Name: "third"})
+		assert.NoError(t, err)
+		assert.Len(t, readFile(t, fs), 3)
+
+		// Every record of a bulk change counts
+		_, err = s.CreateMany([]*testJSONDataInt64{{Name: "fourth"}, {Name: "fifth"}})
+		assert.NoError(t, err)
+		assert.Len(t, readFile(t, fs), 5)
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithFlushPolicy(FlushInterval(10*time.Millisecond)))
+		assert.NoError(t, err)
+		defer s.Close()
+
+		_, err = s.Create(&testJSONDataInt64{Name: "new"})
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			return len(readFile(t, fs)) == 2
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithFlushPolicy(FlushInterval(time.Hour)))
+		assert.NoError(t, err)
+
+		_, err = s.Create(&testJSONDataInt64{Name: "new"})
+		assert.NoError(t, err)
+
+		err = s.Close()
+		assert.NoError(t, err)
+		assert.Len(t, readFile(t, fs), 2)
+
+		// Changes are rejected after close
+		_, err = s.Create(&testJSONDataInt64{Name: "closed"})
+		assert.ErrorIs(t, err, ErrorClosed)
+		err = s.Close()
+		assert.NoError(t, err)
+	})
+
+	t.Run("BackgroundError", func(t *testing.T) {
+		fs := getJSONFilesystem(t)
+		s, err := NewJSONWriter[int64, *testJSONDataInt64](afero.NewReadOnlyFs(fs), "./int64.json", newIdFunc, WithFlushPolicy(FlushInterval(time.Millisecond)))
+		assert.NoError(t, err)
+
+		_, err = s.Create(&testJSONDataInt64{Name: "new"})
+		assert.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		// The failed background flush is reported and the change stays pending
+		err = s.Close()
+		assert.Error(t, err)
+		assert.Len(t, readFile(t, fs), 1)
+	})
+}
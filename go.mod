@@ -3,15 +3,15 @@ module github.com/thegrandpackard/gofilestorer
 go 1.19
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/google/uuid v1.6.0
 	github.com/spf13/afero v1.9.3
 	github.com/stretchr/testify v1.8.1
-	github.com/trimmer-io/go-csv v1.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/text v0.3.4 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,207 @@
+package gofilestorer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// indexDef describes an index registered through an option
+type indexDef struct {
+	name   string
+	key    any
+	unique bool
+}
+
+// index maps the keys extracted from records to the IDs of those records
+type index[K comparable, V any] struct {
+	key     func(V) any
+	unique  bool
+	entries map[any][]K
+	keys    map[K]any
+}
+
+// IndexConflictError is returned when a change would store two records with
+// the same key in a unique index
+type IndexConflictError struct {
+	Index string
+	Key   any
+}
+
+func (e *IndexConflictError) Error() string {
+	return fmt.Sprintf("%s: %q has key %v", ErrorIndexConflict, e.Index, e.Key)
+}
+
+func (e *IndexConflictError) Unwrap() error {
+	return ErrorIndexConflict
+}
+
+// Register a secondary index on the key returned by key, which must return a
+// comparable value. Changes storing a record with a key that is not comparable
+// fail with ErrorInvalidOption. Records are looked up by key with ReadBy.
+func WithIndex[V any](name string, key func(V) any) Option {
+	return func(o *options) {
+		o.indexDefs = append(o.indexDefs, indexDef{name: name, key: key})
+	}
+}
+
+// Register a secondary index like WithIndex that rejects changes storing two
+// records with the same key with an *IndexConflictError
+func WithUniqueIndex[V any](name string, key func(V) any) Option {
+	return func(o *options) {
+		o.indexDefs = append(o.indexDefs, indexDef{name: name, key: key, unique: true})
+	}
+}
+
+// check the index definitions against the record type of the storer
+func (s *storer[K, V]) checkIndexDefs() error {
+	names := map[string]bool{}
+	for _, def := range s.indexDefs {
+		if names[def.name] {
+			return fmt.Errorf("%w: duplicate index %q", ErrorInvalidOption, def.name)
+		}
+		names[def.name] = true
+
+		if _, ok := def.key.(func(V) any); !ok {
+			return fmt.Errorf("%w: index %q key func has type %T", ErrorInvalidOption, def.name, def.key)
+		}
+	}
+	return nil
+}
+
+// build the indexes of the storer for r
+func (s *storer[K, V]) buildIndexes(r *records[K, V]) error {
+	r.indexes = map[string]*index[K, V]{}
+	for _, def := range s.indexDefs {
+		r.indexes[def.name] = &index[K, V]{
+			key:     def.key.(func(V) any),
+			unique:  def.unique,
+			entries: map[any][]K{},
+			keys:    map[K]any{},
+		}
+	}
+
+	for _, record := range r.data {
+		if err := r.checkIndexes(record.GetID(), record); err != nil {
+			return err
+		}
+		r.addToIndexes(record.GetID(), record)
+	}
+
+	return nil
+}
+
+// check that storing data under id does not conflict with a unique index
+func (r *records[K, V]) checkIndexes(id K, data V) error {
+	if err := r.checkIndexKeys(data); err != nil {
+		return err
+	}
+	for name, idx := range r.indexes {
+		if !idx.unique {
+			continue
+		}
+		key := idx.key(data)
+		for _, other := range idx.entries[key] {
+			if other != id {
+				return &IndexConflictError{Index: name, Key: key}
+			}
+		}
+	}
+	return nil
+}
+
+// check that the keys of data can be stored in the indexes
+func (r *records[K, V]) checkIndexKeys(data V) error {
+	for name, idx := range r.indexes {
+		if key := idx.key(data); !isComparable(key) {
+			return fmt.Errorf("%w: index %q key has type %T, which is not comparable", ErrorInvalidOption, name, key)
+		}
+	}
+	return nil
+}
+
+// report whether key can be used as a map key
+func isComparable(key any) bool {
+	t := reflect.TypeOf(key)
+	return t == nil || t.Comparable()
+}
+
+// add the record stored under id to the indexes
+func (r *records[K, V]) addToIndexes(id K, data V) {
+	for _, idx := range r.indexes {
+		key := idx.key(data)
+		idx.entries[key] = append(idx.entries[key], id)
+		idx.keys[id] = key
+	}
+}
+
+// remove the record stored under id from the indexes, using the key it was
+// indexed under in case the record has been modified since
+func (r *records[K, V]) removeFromIndexes(id K) {
+	for _, idx := range r.indexes {
+		key, ok := idx.keys[id]
+		if !ok {
+			continue
+		}
+		delete(idx.keys, id)
+
+		ids := idx.entries[key]
+		for i, other := range ids {
+			if other == id {
+				ids = append(ids[:i:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(idx.entries, key)
+		} else {
+			idx.entries[key] = ids
+		}
+	}
+}
+
+// copy the index so that changes to the copy do not affect idx
+func (idx *index[K, V]) copy() *index[K, V] {
+	c := &index[K, V]{
+		key:     idx.key,
+		unique:  idx.unique,
+		entries: make(map[any][]K, len(idx.entries)),
+		keys:    make(map[K]any, len(idx.keys)),
+	}
+	for key, ids := range idx.entries {
+		c.entries[key] = append([]K(nil), ids...)
+	}
+	for id, key := range idx.keys {
+		c.keys[id] = key
+	}
+	return c
+}
+
+// read the records stored under key in the named index
+func (s *storer[K, V]) ReadBy(name string, key any) ([]V, error) {
+	return s.ReadByContext(context.Background(), name, key)
+}
+
+// read the records stored under key in the named index, giving up when ctx is
+// done before the storer can be read
+func (s *storer[K, V]) ReadByContext(ctx context.Context, name string, key any) ([]V, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mutex.RUnlock()
+
+	idx, ok := s.indexes[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrorIndexNotExists, name)
+	}
+
+	// No record is stored under a key that is not comparable
+	data := []V{}
+	if !isComparable(key) {
+		return data, nil
+	}
+	for _, id := range idx.entries[key] {
+		data = append(data, s.dataMap[id])
+	}
+	return s.cloneAll(s.visibleRecords(data))
+}
@@ -0,0 +1,120 @@
+package gofilestorer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReaderIndex(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataString, data *testJSONDataString) string {
+		return data.ID
+	}
+	byName := func(d *testJSONDataString) any {
+		return d.Name
+	}
+	byInitial := func(d *testJSONDataString) any {
+		return d.Name[:1]
+	}
+
+	s, err := NewJSONWriter[string, *testJSONDataString](fs, "./string.json", newIdFunc,
+		WithUniqueIndex("name", byName),
+		WithIndex("initial", byInitial),
+	)
+	assert.NoError(t, err)
+
+	// Indexes are built from the file
+	read, err := s.ReadBy("name", "Foobar")
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	assert.Equal(t, "foobar", read[0].ID)
+
+	// Create
+	_, err = s.Create(&testJSONDataString{ID: "fizz", Name: "Fizz"})
+	assert.NoError(t, err)
+	_, err = s.Create(&testJSONDataString{ID: "buzz", Name: "Buzz"})
+	assert.NoError(t, err)
+
+	read, err = s.ReadBy("initial", "F")
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "foobar", read[0].ID)
+	assert.Equal(t, "fizz", read[1].ID)
+
+	// Unique conflict on create
+	_, err = s.Create(&testJSONDataString{ID: "other", Name: "Fizz"})
+	assert.ErrorIs(t, err, ErrorIndexConflict)
+	var conflict *IndexConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, "name", conflict.Index)
+	assert.Equal(t, "Fizz", conflict.Key)
+	_, err = s.ReadOne("other")
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+
+	// Update moves the record between keys
+	_, err = s.Update("fizz", &testJSONDataString{ID: "fizz", Name: "Bazz"})
+	assert.NoError(t, err)
+
+	read, err = s.ReadBy("name", "Fizz")
+	assert.NoError(t, err)
+	assert.Len(t, read, 0)
+	read, err = s.ReadBy("initial", "B")
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+
+	// Unique conflict on update
+	_, err = s.Update("fizz", &testJSONDataString{ID: "fizz", Name: "Buzz"})
+	assert.ErrorIs(t, err, ErrorIndexConflict)
+
+	// Delete
+	err = s.Delete("buzz")
+	assert.NoError(t, err)
+
+	read, err = s.ReadBy("name", "Buzz")
+	assert.NoError(t, err)
+	assert.Len(t, read, 0)
+
+	// Unknown index
+	_, err = s.ReadBy("missing", "Buzz")
+	assert.ErrorIs(t, err, ErrorIndexNotExists)
+
+	// Index key func with mismatched types
+	_, err = NewJSONReader[string, *testJSONDataString](fs, "./string.json",
+		WithIndex("name", func(d *testJSONDataInt64) any { return d.Name }),
+	)
+	assert.ErrorIs(t, err, ErrorInvalidOption)
+
+	// Keys that are not comparable are rejected instead of panicking
+	_, err = NewJSONReader[string, *testJSONDataString](fs, "./string.json",
+		WithIndex("bytes", func(d *testJSONDataString) any { return []byte(d.Name) }),
+	)
+	assert.ErrorIs(t, err, ErrorInvalidOption)
+
+	s, err = NewJSONWriter[string, *testJSONDataString](fs, "./string.json", newIdFunc,
+		WithIndex("tags", func(d *testJSONDataString) any {
+			if d.Name == "list" {
+				return []string{d.Name}
+			}
+			return d.Name
+		}),
+	)
+	assert.NoError(t, err)
+	_, err = s.Create(&testJSONDataString{ID: "list", Name: "list"})
+	assert.ErrorIs(t, err, ErrorInvalidOption)
+	_, err = s.ReadOne("list")
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+	_, err = s.Update("foobar", &testJSONDataString{ID: "foobar", Name: "list"})
+	assert.ErrorIs(t, err, ErrorInvalidOption)
+	read, err = s.ReadBy("tags", []string{"list"})
+	assert.NoError(t, err)
+	assert.Len(t, read, 0)
+
+	// Unique conflict in the file
+	_, err = NewJSONReader[string, *testJSONDataString](fs, "./string.json",
+		WithUniqueIndex("constant", func(d *testJSONDataString) any { return true }),
+	)
+	assert.ErrorIs(t, err, ErrorIndexConflict)
+}
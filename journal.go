@@ -0,0 +1,125 @@
+package gofilestorer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// journalRecord is a single change as stored in the journal. Each line of the
+// journal holds the records of one persisted batch of changes as JSON, with
+// the changed record encoded in the format of the file.
+type journalRecord[K comparable] struct {
+	Op   changeOp `json:"op"`
+	ID   K        `json:"id"`
+	Data string   `json:"data,omitempty"`
+}
+
+// the journal is kept next to the file it belongs to
+func (s *storer[K, V]) journalFileName() string {
+	return s.fileName + ".wal"
+}
+
+// append the changes to the journal as a single line
+func (s *storer[K, V]) appendJournal(changes []change[K, V]) error {
+	records := make([]journalRecord[K], 0, len(changes))
+	for _, c := range changes {
+		record := journalRecord[K]{Op: c.op, ID: c.id}
+		if c.op != opDelete {
+			data, err := s.encodeRecord(c.new)
+			if err != nil {
+				return fmt.Errorf("error marshaling journal record: %w", err)
+			}
+			record.Data = string(data)
+		}
+		records = append(records, record)
+	}
+	line, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("error marshaling journal record: %w", err)
+	}
+	line = append(line, '\n')
+
+	f, err := s.fs.OpenFile(s.journalFileName(), os.O_WRONLY|os.O_CREATE, s.fileMode)
+	if err != nil {
+		return fmt.Errorf("error opening journal: %w", err)
+	}
+	defer f.Close()
+
+	// Drop any torn line left behind by an earlier failed write so that the
+	// new line starts at the end of the last complete one
+	if err := f.Truncate(s.journalSize); err != nil {
+		return fmt.Errorf("error truncating journal: %w", err)
+	}
+	if _, err := f.Seek(s.journalSize, io.SeekStart); err != nil {
+		return fmt.Errorf("error seeking journal: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Truncate(s.journalSize)
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Truncate(s.journalSize)
+		return fmt.Errorf("error syncing journal: %w", err)
+	}
+
+	s.journalEntries++
+	s.journalSize += int64(len(line))
+	s.updateStamp()
+
+	return nil
+}
+
+// apply the changes recorded in the journal to the records read from the file,
+// returning the number of entries and the size of the complete lines. Changes
+// are applied as upserts and idempotent deletes so that a journal that was
+// already compacted into the file can safely be replayed again.
+func (s *storer[K, V]) replayJournal(r *records[K, V]) (entries int, size int64, err error) {
+	dataBytes, err := afero.ReadFile(s.fs, s.journalFileName())
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, fmt.Errorf("error reading journal: %w", err)
+	}
+
+	for lineNo := 1; ; lineNo++ {
+		// A trailing line without a newline is a torn write and is ignored
+		end := bytes.IndexByte(dataBytes, '\n')
+		if end < 0 {
+			break
+		}
+		line := dataBytes[:end]
+		dataBytes = dataBytes[end+1:]
+
+		records := []journalRecord[K]{}
+		if err := json.Unmarshal(line, &records); err != nil {
+			return 0, 0, fmt.Errorf("error unmarshaling journal line %d: %w", lineNo, err)
+		}
+		for _, record := range records {
+			switch record.Op {
+			case opCreate, opUpdate:
+				data, err := s.decodeRecord([]byte(record.Data))
+				if err != nil {
+					return 0, 0, fmt.Errorf("error unmarshaling journal line %d: %w", lineNo, err)
+				}
+				if err := r.checkIndexKeys(data); err != nil {
+					return 0, 0, fmt.Errorf("error replaying journal line %d: %w", lineNo, err)
+				}
+				r.replace(record.ID, data)
+			case opDelete:
+				r.remove(record.ID)
+			default:
+				return 0, 0, fmt.Errorf("error replaying journal line %d: unknown operation %q", lineNo, record.Op)
+			}
+		}
+
+		entries++
+		size += int64(len(line)) + 1
+	}
+
+	return entries, size, nil
+}
@@ -0,0 +1,180 @@
+package gofilestorer
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONWriterJournal(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataInt64, _ *testJSONDataInt64) int64 {
+		id := int64(0)
+		for _, data := range dataArray {
+			if data.ID > id {
+				id = data.ID
+			}
+		}
+		return id + 1
+	}
+
+	snapshot, err := afero.ReadFile(fs, "./int64.json")
+	assert.NoError(t, err)
+
+	s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithJournal(3))
+	assert.NoError(t, err)
+
+	// Create appends to the journal and leaves the snapshot alone
+	_, err = s.Create(&testJSONDataInt64{Name: "second"})
+	assert.NoError(t, err)
+	_, err = s.Create(&testJSONDataInt64{Name: "third"})
+	assert.NoError(t, err)
+
+	current, err := afero.ReadFile(fs, "./int64.json")
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, current)
+
+	journal, err := afero.ReadFile(fs, "./int64.json.wal")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(journal), "\n"))
+
+	// Reopen replays the journal
+	s, err = NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithJournal(3))
+	assert.NoError(t, err)
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 3)
+	assert.Equal(t, "third", read[2].Name)
+
+	// The third entry compacts the journal into the snapshot
+	data, err := s.ReadOne(2)
+	assert.NoError(t, err)
+	data.Name = "updated"
+	_, err = s.Update(2, data)
+	assert.NoError(t, err)
+
+	exists, err := afero.Exists(fs, "./int64.json.wal")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	r, err := NewJSONReader[int64, *testJSONDataInt64](fs, "./int64.json")
+	assert.NoError(t, err)
+	read, err = r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 3)
+	assert.Equal(t, "updated", read[1].Name)
+
+	// Delete
+	err = s.Delete(1)
+	assert.NoError(t, err)
+
+	// A torn line at the end of the journal is ignored and overwritten
+	f, err := fs.OpenFile("./int64.json.wal", os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`[{"op":"delete","id":`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	s, err = NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithJournal(3))
+	assert.NoError(t, err)
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+
+	_, err = s.Create(&testJSONDataInt64{Name: "fourth"})
+	assert.NoError(t, err)
+
+	s, err = NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithJournal(3))
+	assert.NoError(t, err)
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 3)
+	assert.Equal(t, int64(4), read[2].ID)
+	assert.Equal(t, "fourth", read[2].Name)
+
+	// Compact
+	err = s.Compact()
+	assert.NoError(t, err)
+
+	exists, err = afero.Exists(fs, "./int64.json.wal")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// A corrupt line in the middle of the journal is an error
+	err = afero.WriteFile(fs, "./int64.json.wal", []byte("{\n[]\n"), 0644)
+	assert.NoError(t, err)
+	_, err = NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc, WithJournal(3))
+	assert.ErrorContains(t, err, "journal line 1")
+}
+
+func TestCSVWriterJournal(t *testing.T) {
+	fs := getCSVFilesystem(t)
+
+	newIdFunc := func(dataArray []*testCSVData, data *testCSVData) uuid.UUID {
+		return uuid.New()
+	}
+
+	s, err := NewCSVWriter[uuid.UUID, *testCSVData](fs, "./journal.csv", ',', newIdFunc, WithCreateIfMissing(), WithJournal(0))
+	assert.NoError(t, err)
+
+	data := &testCSVData{Name: "new"}
+	_, err = s.Create(data)
+	assert.NoError(t, err)
+
+	s, err = NewCSVWriter[uuid.UUID, *testCSVData](fs, "./journal.csv", ',', newIdFunc, WithJournal(0))
+	assert.NoError(t, err)
+	read, err := s.ReadOne(data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", read.Name)
+	assert.True(t, data.CreatedAt.Equal(read.CreatedAt))
+}
+
+func TestYAMLWriterJournal(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	newIdFunc := func(_ []*testYAMLDataSoftDelete, _ *testYAMLDataSoftDelete) uuid.UUID {
+		return uuid.New()
+	}
+
+	s, err := NewYAMLWriter[uuid.UUID, *testYAMLDataSoftDelete](fs, "./journal.yaml", newIdFunc, WithCreateIfMissing(), WithJournal(0))
+	assert.NoError(t, err)
+
+	data, err := s.Create(&testYAMLDataSoftDelete{testYAMLData: testYAMLData{Name: "new"}, Secret: "secret"})
+	assert.NoError(t, err)
+
+	// Replaying the journal keeps fields that are only written in the format of the file
+	s, err = NewYAMLWriter[uuid.UUID, *testYAMLDataSoftDelete](fs, "./journal.yaml", newIdFunc, WithJournal(0))
+	assert.NoError(t, err)
+	read, err := s.ReadOne(data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", read.Name)
+	assert.Equal(t, "secret", read.Secret)
+
+	// Readers without the option replay the journal too
+	r, err := NewYAMLReader[uuid.UUID, *testYAMLDataSoftDelete](fs, "./journal.yaml")
+	assert.NoError(t, err)
+	read, err = r.ReadOne(data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", read.Secret)
+
+	// Writers without the option compact the journal into the file
+	s, err = NewYAMLWriter[uuid.UUID, *testYAMLDataSoftDelete](fs, "./journal.yaml", newIdFunc)
+	assert.NoError(t, err)
+	_, err = s.Create(&testYAMLDataSoftDelete{testYAMLData: testYAMLData{Name: "second"}})
+	assert.NoError(t, err)
+
+	exists, err := afero.Exists(fs, "./journal.yaml.wal")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	r, err = NewYAMLReader[uuid.UUID, *testYAMLDataSoftDelete](fs, "./journal.yaml")
+	assert.NoError(t, err)
+	all, err := r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+}
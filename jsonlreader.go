@@ -0,0 +1,75 @@
+package gofilestorer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/afero"
+)
+
+type jsonlReader[K comparable, V reader[K]] struct {
+	storer[K, V]
+}
+
+// Create a new reader that is backed by a JSON Lines file, which holds one
+// JSON record per line
+func NewJSONLReader[K comparable, V reader[K]](fs afero.Fs, fileName string, opts ...Option) (Reader[K, V], error) {
+	s := &jsonlReader[K, V]{
+		storer: storer[K, V]{
+			fs:       fs,
+			fileName: fileName,
+			codec:    jsonlCodec[V]{},
+			options:  newOptions(opts),
+		},
+	}
+
+	// Read file
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+type jsonlCodec[V any] struct{}
+
+// unmarshal each non-empty line into a record. A last line without a newline
+// that is not valid JSON is left behind by a failed append and ignored.
+func (c jsonlCodec[V]) decode(dataBytes []byte) ([]V, error) {
+	torn := -1
+	if end := bytes.LastIndexByte(dataBytes, '\n'); end < len(dataBytes)-1 && !c.complete(dataBytes[end+1:]) {
+		torn = bytes.Count(dataBytes, []byte{'\n'}) + 1
+	}
+
+	data := []V{}
+	scanner := bufio.NewScanner(bytes.NewReader(dataBytes))
+	scanner.Buffer(nil, len(dataBytes)+1)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		if lineNo == torn {
+			break
+		}
+
+		record := newRecord[V]()
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		data = append(data, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// report whether the last line of a file holds a complete JSON value
+func (jsonlCodec[V]) complete(line []byte) bool {
+	line = bytes.TrimSpace(line)
+	return len(line) == 0 || json.Valid(line)
+}
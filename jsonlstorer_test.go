@@ -0,0 +1,147 @@
+package gofilestorer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func getJSONLFilesystem(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	// create test files and directories
+	err := fs.MkdirAll("data", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "int64.jsonl", []byte(`{"id": 1, "created_at": "2022-12-27T12:45:51.8347046-08:00", "name": "Foobar"}
+
+{"id": 2, "created_at": "2022-12-27T12:45:51.8347046-08:00", "name": "Barfoo"}`), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "data/invalid.jsonl", []byte(`{"id": 1, "name": "Foobar"}
+{"id": 2, "name": }
+`), 0644)
+	assert.NoError(t, err)
+
+	return fs
+}
+
+func TestJSONLReader(t *testing.T) {
+	fs := getJSONLFilesystem(t)
+
+	// Read non-existant file
+	s, err := NewJSONLReader[int64, *testJSONDataInt64](fs, "./foobar.jsonl")
+	assert.Error(t, err)
+	assert.Nil(t, s)
+
+	// Read invalid file
+	s, err = NewJSONLReader[int64, *testJSONDataInt64](fs, "./data/invalid.jsonl")
+	assert.ErrorContains(t, err, "line 2")
+	assert.Nil(t, s)
+
+	// Read test file
+	s, err = NewJSONLReader[int64, *testJSONDataInt64](fs, "./int64.jsonl")
+	assert.NoError(t, err)
+
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "Foobar", read[0].Name)
+	assert.Equal(t, int64(2), read[1].ID)
+}
+
+func TestJSONLWriter(t *testing.T) {
+	fs := getJSONLFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataInt64, _ *testJSONDataInt64) int64 {
+		id := int64(0)
+		for _, d := range dataArray {
+			if d.ID > id {
+				id = d.ID
+			}
+		}
+		return id + 1
+	}
+
+	s, err := NewJSONLWriter[int64, *testJSONDataInt64](fs, "./int64.jsonl", newIdFunc)
+	assert.NoError(t, err)
+	original, err := afero.ReadFile(fs, "./int64.jsonl")
+	assert.NoError(t, err)
+
+	// Create appends a line without rewriting the file
+	_, err = s.CreateMany([]*testJSONDataInt64{{Name: "third"}, {Name: "fourth"}})
+	assert.NoError(t, err)
+	dataBytes, err := afero.ReadFile(fs, "./int64.jsonl")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(dataBytes), string(original)+"\n"))
+	assert.Equal(t, 5, strings.Count(string(dataBytes), "\n"))
+
+	// Update and Delete rewrite the file
+	_, err = s.Update(1, &testJSONDataInt64{Name: "updated"})
+	assert.NoError(t, err)
+	err = s.Delete(2)
+	assert.NoError(t, err)
+	dataBytes, err = afero.ReadFile(fs, "./int64.jsonl")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(dataBytes), "\n"))
+
+	// Reopen
+	r, err := NewJSONLReader[int64, *testJSONDataInt64](fs, "./int64.jsonl")
+	assert.NoError(t, err)
+	read, err := r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 3)
+	assert.Equal(t, "updated", read[0].Name)
+	assert.Equal(t, "third", read[1].Name)
+	assert.Equal(t, "fourth", read[2].Name)
+
+	// A failed append is not visible
+	failing := &failingFs{Fs: fs, fail: true}
+	s, err = NewJSONLWriter[int64, *testJSONDataInt64](failing, "./int64.jsonl", newIdFunc)
+	assert.NoError(t, err)
+	_, err = s.Create(&testJSONDataInt64{Name: "failed"})
+	assert.ErrorIs(t, err, errTestWriteFailed)
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 3)
+
+	// Create missing file
+	s, err = NewJSONLWriter[int64, *testJSONDataInt64](fs, "./missing/data.jsonl", newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+	_, err = s.Create(&testJSONDataInt64{Name: "new"})
+	assert.NoError(t, err)
+	dataBytes, err = afero.ReadFile(fs, "./missing/data.jsonl")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(dataBytes), "\n"))
+}
+
+func TestJSONLWriterTornLine(t *testing.T) {
+	newIdFunc := func(dataArray []*testJSONDataInt64, _ *testJSONDataInt64) int64 {
+		return int64(len(dataArray) + 1)
+	}
+
+	// A crash while appending leaves a partial last line behind
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, "torn.jsonl", []byte(`{"id": 1, "name": "Foobar"}
+{"id":2,"na`), 0644)
+	assert.NoError(t, err)
+
+	s, err := NewJSONLWriter[int64, *testJSONDataInt64](fs, "./torn.jsonl", newIdFunc)
+	assert.NoError(t, err)
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+
+	// The next change rewrites the file without it
+	_, err = s.Create(&testJSONDataInt64{Name: "new"})
+	assert.NoError(t, err)
+	dataBytes, err := afero.ReadFile(fs, "./torn.jsonl")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(dataBytes), `"na`+"\n")
+
+	r, err := NewJSONLReader[int64, *testJSONDataInt64](fs, "./torn.jsonl")
+	assert.NoError(t, err)
+	read, err = r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "new", read[1].Name)
+}
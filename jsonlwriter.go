@@ -0,0 +1,54 @@
+package gofilestorer
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/spf13/afero"
+)
+
+type jsonlWriter[K comparable, V writer[K]] struct {
+	fileWriter[K, V]
+}
+
+// Create a new writer that is backed by a JSON Lines file. Created records are
+// appended to the file, while updates and deletes rewrite it.
+func NewJSONLWriter[K comparable, V writer[K]](fs afero.Fs, fileName string, newIDFunc func([]V, V) K, opts ...Option) (Writer[K, V], error) {
+	s := &jsonlWriter[K, V]{
+		fileWriter: fileWriter[K, V]{
+			storer: storer[K, V]{
+				fs:        fs,
+				fileName:  fileName,
+				newIDFunc: newIDFunc,
+				codec:     jsonlCodec[V]{},
+				options:   newOptions(opts),
+			},
+		},
+	}
+
+	// Read file
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// marshal each record into a line
+func (c jsonlCodec[V]) encode(data []V) ([]byte, error) {
+	return c.encodeAppend(data)
+}
+
+// marshal each record into a line, to be appended to an existing file
+func (jsonlCodec[V]) encodeAppend(data []V) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, record := range data {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
@@ -59,6 +59,10 @@ func (d *testJSONDataUUID) SetID(id uuid.UUID) {
 	d.ID = id
 }
 
+func (d *testJSONDataUUID) GetCreatedAt() time.Time {
+	return d.CreatedAt
+}
+
 func (d *testJSONDataUUID) SetCreatedAt(createdAt time.Time) {
 	d.CreatedAt = createdAt
 }
@@ -188,6 +192,10 @@ func (d *testJSONDataInt64) SetID(id int64) {
 	d.ID = id
 }
 
+func (d *testJSONDataInt64) GetCreatedAt() time.Time {
+	return d.CreatedAt
+}
+
 func (d *testJSONDataInt64) SetCreatedAt(createdAt time.Time) {
 	d.CreatedAt = createdAt
 }
@@ -289,6 +297,10 @@ func (d *testJSONDataString) SetID(id string) {
 	d.ID = id
 }
 
+func (d *testJSONDataString) GetCreatedAt() time.Time {
+	return d.CreatedAt
+}
+
 func (d *testJSONDataString) SetCreatedAt(createdAt time.Time) {
 	d.CreatedAt = createdAt
 }
@@ -374,3 +386,189 @@ func TestJSONDataString(t *testing.T) {
 	err = s.Delete(data.ID)
 	assert.Error(t, err)
 }
+
+func TestJSONWriterAtomicWrite(t *testing.T) {
+	newIdFunc := func(_ []*testJSONDataUUID, _ *testJSONDataUUID) uuid.UUID {
+		return uuid.New()
+	}
+
+	for name, fs := range map[string]afero.Fs{
+		"MemMapFs": getJSONFilesystem(t),
+		"OsFs":     afero.NewBasePathFs(afero.NewOsFs(), t.TempDir()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := afero.WriteFile(fs, "atomic.json", []byte(`[]`), 0600)
+			assert.NoError(t, err)
+
+			s, err := NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, "atomic.json", newIdFunc)
+			assert.NoError(t, err)
+
+			// Create
+			_, err = s.Create(&testJSONDataUUID{Name: "new"})
+			assert.NoError(t, err)
+
+			// No temporary files are left behind
+			files, err := afero.Glob(fs, ".atomic.json.tmp-*")
+			assert.NoError(t, err)
+			assert.Empty(t, files)
+
+			// The file keeps its mode
+			info, err := fs.Stat("atomic.json")
+			assert.NoError(t, err)
+			assert.Equal(t, "-rw-------", info.Mode().Perm().String())
+
+			// Reopen
+			s, err = NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, "atomic.json", newIdFunc)
+			assert.NoError(t, err)
+			read, err := s.ReadAll()
+			assert.NoError(t, err)
+			assert.Len(t, read, 1)
+			assert.Equal(t, "new", read[0].Name)
+		})
+	}
+
+	// Write failure leaves the original file intact
+	fs := getJSONFilesystem(t)
+	original, err := afero.ReadFile(fs, "uuid.json")
+	assert.NoError(t, err)
+
+	s, err := NewJSONWriter[uuid.UUID, *testJSONDataUUID](afero.NewReadOnlyFs(fs), "uuid.json", newIdFunc)
+	assert.NoError(t, err)
+	_, err = s.Create(&testJSONDataUUID{Name: "new"})
+	assert.Error(t, err)
+
+	current, err := afero.ReadFile(fs, "uuid.json")
+	assert.NoError(t, err)
+	assert.Equal(t, original, current)
+}
+
+func TestJSONWriterCreateIfMissing(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(_ []*testJSONDataUUID, _ *testJSONDataUUID) uuid.UUID {
+		return uuid.New()
+	}
+
+	// Create missing file and parent directories
+	s, err := NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, "./missing/dir/data.json", newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	dataBytes, err := afero.ReadFile(fs, "./missing/dir/data.json")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(dataBytes))
+
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 0)
+
+	// Create
+	_, err = s.Create(&testJSONDataUUID{Name: "new"})
+	assert.NoError(t, err)
+
+	// Existing file is left untouched
+	s, err = NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, "./missing/dir/data.json", newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	assert.Equal(t, "new", read[0].Name)
+}
+
+func TestJSONWriterDuplicateIDs(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataString, data *testJSONDataString) string {
+		return data.ID
+	}
+
+	s, err := NewJSONWriter[string, *testJSONDataString](fs, "./string.json", newIdFunc)
+	assert.NoError(t, err)
+
+	// Create with an existing ID
+	data := &testJSONDataString{ID: "foobar", Name: "Duplicate"}
+	_, err = s.Create(data)
+	assert.ErrorIs(t, err, ErrorDataExists)
+	assert.Empty(t, data.CreatedAt)
+
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	assert.Equal(t, "Foobar", read[0].Name)
+
+	// Read file with duplicate IDs
+	err = afero.WriteFile(fs, "duplicate.json", []byte(`[
+		{"id": "foobar", "name": "First"},
+		{"id": "fizz", "name": "Fizz"},
+		{"id": "foobar", "name": "Second"}
+	]`), 0644)
+	assert.NoError(t, err)
+
+	_, err = NewJSONWriter[string, *testJSONDataString](fs, "./duplicate.json", newIdFunc)
+	assert.ErrorIs(t, err, ErrorDuplicateID)
+
+	// Report duplicate IDs instead
+	reported := []error{}
+	s, err = NewJSONWriter[string, *testJSONDataString](fs, "./duplicate.json", newIdFunc, WithDuplicateIDHandler(func(err error) {
+		reported = append(reported, err)
+	}))
+	assert.NoError(t, err)
+	assert.Len(t, reported, 1)
+	assert.ErrorIs(t, reported[0], ErrorDuplicateID)
+
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "fizz", read[0].ID)
+	assert.Equal(t, "Second", read[1].Name)
+}
+
+func TestJSONWriterUpdate(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataString, data *testJSONDataString) string {
+		return data.ID
+	}
+
+	s, err := NewJSONWriter[string, *testJSONDataString](fs, "./string.json", newIdFunc)
+	assert.NoError(t, err)
+
+	original, err := s.ReadOne("foobar")
+	assert.NoError(t, err)
+	createdAt := original.CreatedAt
+
+	// Update with a new struct
+	_, err = s.Update("foobar", &testJSONDataString{ID: "foobar", Name: "updated"})
+	assert.NoError(t, err)
+
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	assert.Equal(t, "updated", read[0].Name)
+	assert.True(t, createdAt.Equal(read[0].CreatedAt))
+	assert.NotNil(t, read[0].UpdatedAt)
+
+	one, err := s.ReadOne("foobar")
+	assert.NoError(t, err)
+	assert.Same(t, read[0], one)
+
+	// Update without an ID uses the given ID
+	data := &testJSONDataString{Name: "no id"}
+	_, err = s.Update("foobar", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", data.ID)
+
+	// Update with a different ID
+	_, err = s.Update("foobar", &testJSONDataString{ID: "other", Name: "mismatch"})
+	assert.ErrorIs(t, err, ErrorIDMismatch)
+
+	// The file reflects the update
+	s, err = NewJSONWriter[string, *testJSONDataString](fs, "./string.json", newIdFunc)
+	assert.NoError(t, err)
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	assert.Equal(t, "foobar", read[0].ID)
+	assert.Equal(t, "no id", read[0].Name)
+	assert.True(t, createdAt.Equal(read[0].CreatedAt))
+}
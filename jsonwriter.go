@@ -1,103 +1,65 @@
 package gofilestorer
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"time"
 
 	"github.com/spf13/afero"
 )
 
+// JSONWriter is implemented by the writers returned by NewJSONWriter, which
+// can also apply JSON Merge Patches to records:
+//
+//	data, err := s.(JSONWriter[K, V]).MergePatch(id, patch)
+type JSONWriter[K comparable, V writer[K]] interface {
+	Writer[K, V]
+
+	MergePatch(K, []byte) (V, error)
+	MergePatchContext(context.Context, K, []byte) (V, error)
+}
+
 type jsonWriter[K comparable, V writer[K]] struct {
-	jsonReader[K, V]
+	fileWriter[K, V]
 }
 
 // Create a new writer that is backed by a JSON file
-func NewJSONWriter[K comparable, V writer[K]](fs afero.Fs, fileName string, newIDFunc func([]V, V) K) (Writer[K, V], error) {
+func NewJSONWriter[K comparable, V writer[K]](fs afero.Fs, fileName string, newIDFunc func([]V, V) K, opts ...Option) (Writer[K, V], error) {
 	s := &jsonWriter[K, V]{
-		jsonReader: jsonReader[K, V]{
+		fileWriter: fileWriter[K, V]{
 			storer: storer[K, V]{
 				fs:        fs,
 				fileName:  fileName,
 				newIDFunc: newIDFunc,
+				codec:     jsonCodec[V]{},
+				options:   newOptions(opts),
 			},
 		},
 	}
 
 	// Read file
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	if err := s.readFile(); err != nil {
+	if err := s.open(); err != nil {
 		return nil, err
 	}
 
 	return s, nil
 }
 
-// write the file from the storer
-func (s *jsonWriter[K, V]) writeFile() error {
-	// Marshal JSON to bytes
-	dataBytes, err := json.Marshal(s.data)
-	if err != nil {
-		return fmt.Errorf("error marshaling data: %w", err)
-	}
-
-	// Write file to disk
-	if err := afero.WriteFile(s.fs, s.fileName, dataBytes, 0644); err != nil {
-		return fmt.Errorf("error writing file: %w", err)
-	}
-
-	return nil
+// marshal the records into a JSON array
+func (jsonCodec[V]) encode(data []V) ([]byte, error) {
+	return json.Marshal(data)
 }
 
-// create a new record in the storer and write changes to file
-func (s *jsonWriter[K, V]) Create(data V) (V, error) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	id := s.newIDFunc(s.data, data)
-	data.SetID(id)
-	data.SetCreatedAt(time.Now())
-	s.data = append(s.data, data)
-	s.dataMap[id] = data
-
-	return data, s.writeFile()
+// apply a JSON Merge Patch (RFC 7386) to the stored record with the given id
+// and write the patched record to file. Members set to null in the patch are
+// reset to their zero value.
+func (s *jsonWriter[K, V]) MergePatch(id K, patch []byte) (V, error) {
+	return s.MergePatchContext(context.Background(), id, patch)
 }
 
-// update an existing record in the storer and write changes to file
-func (s *jsonWriter[K, V]) Update(id K, data V) (V, error) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	_, ok := s.dataMap[id]
-	if ok {
-		data.SetUpdatedAt(time.Now())
-		s.dataMap[data.GetID()] = data
-		for _, d := range s.data {
-			if d.GetID() == id {
-				return data, s.writeFile()
-			}
-		}
-	}
-
-	return *new(V), ErrorDataNotExists
-}
-
-// delete an existing record in the storer and write changes to file
-func (s *jsonWriter[K, V]) Delete(id K) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	_, ok := s.dataMap[id]
-	if ok {
-		delete(s.dataMap, id)
-		for i, data := range s.data {
-			if data.GetID() == id {
-				s.data = append(s.data[:i], s.data[i+1:]...)
-				return s.writeFile()
-			}
-		}
-	}
-
-	return ErrorDataNotExists
+// apply a JSON Merge Patch like MergePatch, giving up when ctx is done before
+// the record is changed
+func (s *jsonWriter[K, V]) MergePatchContext(ctx context.Context, id K, patch []byte) (V, error) {
+	return s.patch(ctx, id, func(stored V) (V, error) {
+		return mergePatchRecord(stored, patch)
+	})
 }
@@ -0,0 +1,175 @@
+package gofilestorer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// processLocks serializes access to lock files within this process, which
+// is all the locking filesystems without OS file handles such as MemMapFs get
+var processLocks = struct {
+	sync.Mutex
+	locks map[processLockKey]*sync.Mutex
+}{locks: map[processLockKey]*sync.Mutex{}}
+
+type processLockKey struct {
+	fs       afero.Fs
+	fileName string
+}
+
+// get the process wide lock for a lock file
+func processLock(fs afero.Fs, fileName string) *sync.Mutex {
+	processLocks.Lock()
+	defer processLocks.Unlock()
+
+	key := processLockKey{fs: fs, fileName: fileName}
+	mu, ok := processLocks.locks[key]
+	if !ok {
+		mu = &sync.Mutex{}
+		processLocks.locks[key] = mu
+	}
+	return mu
+}
+
+// get the OS file behind an afero file, if there is one
+func osFile(f afero.File) (*os.File, bool) {
+	switch f := f.(type) {
+	case *os.File:
+		return f, true
+	case *afero.BasePathFile:
+		return osFile(f.File)
+	}
+	return nil, false
+}
+
+// the lock file is kept next to the file it belongs to
+func (s *storer[K, V]) lockFileName() string {
+	return s.fileName + ".lock"
+}
+
+// acquire an exclusive lock on the lock file, held until the returned
+// function is called, giving up when ctx is done. Without WithFileLock this
+// does nothing.
+func (s *storer[K, V]) lockFile(ctx context.Context) (func(), error) {
+	if !s.fileLock {
+		return func() {}, nil
+	}
+
+	mu := processLock(s.fs, s.lockFileName())
+	if err := lockContext(ctx, mu.TryLock, mu.Lock, mu.Unlock); err != nil {
+		return nil, err
+	}
+
+	f, err := s.fs.OpenFile(s.lockFileName(), os.O_RDWR|os.O_CREATE, s.fileMode)
+	if err != nil {
+		mu.Unlock()
+		return nil, fmt.Errorf("error opening lock file: %w", err)
+	}
+	if o, ok := osFile(f); ok {
+		if err := flock(ctx, o); err != nil {
+			_ = f.Close()
+			mu.Unlock()
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("error locking file: %w", err)
+		}
+	}
+
+	// Closing the lock file releases the OS lock
+	return func() {
+		_ = f.Close()
+		mu.Unlock()
+	}, nil
+}
+
+// lock the file for a change and reload it when it was changed by another
+// process. The storer mutex must be held.
+func (s *storer[K, V]) lockFileForWrite(ctx context.Context) (func(), error) {
+	unlock, err := s.lockFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refresh(); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// fileStamp identifies a version of a file on disk
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func (f fileStamp) equal(o fileStamp) bool {
+	return f.exists == o.exists && f.modTime.Equal(o.modTime) && f.size == o.size
+}
+
+// stat a file into a stamp
+func statFile(fs afero.Fs, fileName string) (fileStamp, error) {
+	info, err := fs.Stat(fileName)
+	if os.IsNotExist(err) {
+		return fileStamp{}, nil
+	} else if err != nil {
+		return fileStamp{}, fmt.Errorf("error reading file info: %w", err)
+	}
+	return fileStamp{exists: true, modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// stat the file and journal of the storer
+func (s *storer[K, V]) statFiles() ([2]fileStamp, error) {
+	file, err := statFile(s.fs, s.fileName)
+	if err != nil {
+		return [2]fileStamp{}, err
+	}
+	journal, err := statFile(s.fs, s.journalFileName())
+	if err != nil {
+		return [2]fileStamp{}, err
+	}
+	return [2]fileStamp{file, journal}, nil
+}
+
+// remember the files as they are after reading or writing them, so that
+// changes made by other processes can be detected
+func (s *storer[K, V]) updateStamp() {
+	stamp, err := s.statFiles()
+	if err != nil {
+		s.logger.Printf("error reading file info %s: %v", s.fileName, err)
+		return
+	}
+	s.stamp = stamp
+}
+
+// report whether the files were changed since they were last read or written,
+// along with their current stamp
+func (s *storer[K, V]) changedOnDisk() (bool, [2]fileStamp, error) {
+	stamp, err := s.statFiles()
+	if err != nil {
+		return false, stamp, err
+	}
+	return !stamp[0].equal(s.stamp[0]) || !stamp[1].equal(s.stamp[1]), stamp, nil
+}
+
+// reload the file when it was changed by another process, reapplying any
+// pending changes on top of it. Without WithFileLock this does nothing.
+func (s *storer[K, V]) refresh() error {
+	if !s.fileLock {
+		return nil
+	}
+
+	changed, _, err := s.changedOnDisk()
+	if err != nil || !changed {
+		return err
+	}
+
+	s.logger.Printf("reloading %s after external change", s.fileName)
+	return s.reload()
+}
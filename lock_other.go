@@ -0,0 +1,14 @@
+//go:build !unix
+
+package gofilestorer
+
+import (
+	"context"
+	"os"
+)
+
+// advisory locks are not supported on this platform, so only the process
+// wide lock is taken
+func flock(context.Context, *os.File) error {
+	return nil
+}
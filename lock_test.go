@@ -0,0 +1,87 @@
+package gofilestorer
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterFileLock(t *testing.T) {
+	newIdFunc := func(_ []*testJSONDataUUID, _ *testJSONDataUUID) uuid.UUID {
+		return uuid.New()
+	}
+
+	for name, fs := range map[string]afero.Fs{
+		"MemMapFs": getJSONFilesystem(t),
+		"OsFs":     afero.NewBasePathFs(afero.NewOsFs(), t.TempDir()),
+	} {
+		for _, journal := range []bool{false, true} {
+			testName := name
+			opts := []Option{WithCreateIfMissing(), WithFileLock()}
+			if journal {
+				testName += "/Journal"
+				opts = append(opts, WithJournal(0))
+			}
+
+			t.Run(testName, func(t *testing.T) {
+				fileName := uuid.NewString() + ".json"
+
+				// Two writers on the same file, as a CLI and a daemon would
+				first, err := NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, fileName, newIdFunc, opts...)
+				assert.NoError(t, err)
+				second, err := NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, fileName, newIdFunc, opts...)
+				assert.NoError(t, err)
+
+				a := &testJSONDataUUID{Name: "first"}
+				_, err = first.Create(a)
+				assert.NoError(t, err)
+
+				// The second writer reloads the change of the first writer
+				b := &testJSONDataUUID{Name: "second"}
+				_, err = second.Create(b)
+				assert.NoError(t, err)
+
+				read, err := second.ReadAll()
+				assert.NoError(t, err)
+				assert.Len(t, read, 2)
+
+				err = first.Delete(b.ID)
+				assert.NoError(t, err)
+
+				r, err := NewJSONReader[uuid.UUID, *testJSONDataUUID](fs, fileName, opts...)
+				assert.NoError(t, err)
+				read, err = r.ReadAll()
+				assert.NoError(t, err)
+				assert.Len(t, read, 1)
+				assert.Equal(t, a.ID, read[0].ID)
+
+				exists, err := afero.Exists(fs, fileName+".lock")
+				assert.NoError(t, err)
+				assert.True(t, exists)
+
+				// Concurrent writers do not lose changes
+				var wg sync.WaitGroup
+				for _, s := range []Writer[uuid.UUID, *testJSONDataUUID]{first, second} {
+					wg.Add(1)
+					go func(s Writer[uuid.UUID, *testJSONDataUUID]) {
+						defer wg.Done()
+						for i := 0; i < 20; i++ {
+							_, err := s.Create(&testJSONDataUUID{Name: "concurrent"})
+							assert.NoError(t, err)
+						}
+					}(s)
+				}
+				wg.Wait()
+
+				r, err = NewJSONReader[uuid.UUID, *testJSONDataUUID](fs, fileName, opts...)
+				assert.NoError(t, err)
+				read, err = r.ReadAll()
+				assert.NoError(t, err)
+				assert.Len(t, read, 41)
+			})
+		}
+	}
+}
@@ -0,0 +1,40 @@
+//go:build unix
+
+package gofilestorer
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+)
+
+// how often a lock held by another process is retried when waiting for it
+// can be cancelled
+const flockRetryInterval = 10 * time.Millisecond
+
+// take an exclusive advisory lock on f, released when f is closed, giving up
+// when ctx is done
+func flock(ctx context.Context, f *os.File) error {
+	if ctx.Done() == nil {
+		for {
+			err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+			if err != syscall.EINTR {
+				return err
+			}
+		}
+	}
+
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err != syscall.EWOULDBLOCK && err != syscall.EINTR {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(flockRetryInterval):
+		}
+	}
+}
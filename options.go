@@ -0,0 +1,157 @@
+package gofilestorer
+
+import (
+	"os"
+	"time"
+)
+
+// Option configures optional behavior of a storer
+type Option func(*options)
+
+type options struct {
+	fileMode           os.FileMode
+	clock              Clock
+	timeLocation       *time.Location
+	timePrecision      time.Duration
+	idFunc             any
+	createIfMissing    bool
+	writePolicy        WritePolicy
+	logger             Logger
+	journal            bool
+	compactAfter       int
+	flushPolicy        FlushPolicy
+	indexDefs          []indexDef
+	duplicateIDHandler func(error)
+	fileLock           bool
+	watchInterval      time.Duration
+	watchHandler       func(error)
+	defensiveCopies    bool
+	softDelete         bool
+	csvQuoting         CSVQuoting
+	csvLineTerminator  LineTerminator
+	csvNoHeader        bool
+}
+
+// build the options from the defaults and the provided options
+func newOptions(opts []Option) options {
+	o := options{
+		fileMode:    0644,
+		clock:       systemClock{},
+		writePolicy: WriteAtomic,
+		logger:      nopLogger{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Set the permissions used when creating the file. Existing files keep their
+// permissions.
+func WithFileMode(fileMode os.FileMode) Option {
+	return func(o *options) {
+		o.fileMode = fileMode
+	}
+}
+
+// Set the function used to generate the ID of created records, taking
+// precedence over the function passed to the writer constructor
+func WithIDFunc[K comparable, V any](newIDFunc func(dataArray []V, data V) K) Option {
+	return func(o *options) {
+		o.idFunc = newIDFunc
+	}
+}
+
+// Create an empty file, including any missing parent directories, when the
+// file does not exist yet
+func WithCreateIfMissing() Option {
+	return func(o *options) {
+		o.createIfMissing = true
+	}
+}
+
+// WritePolicy controls how the file is written to disk
+type WritePolicy int
+
+const (
+	// Write to a temporary file and rename it over the file
+	WriteAtomic WritePolicy = iota
+	// Write directly to the file, for filesystems that do not support renames
+	WriteDirect
+)
+
+// Set the policy used when writing the file
+func WithWritePolicy(writePolicy WritePolicy) Option {
+	return func(o *options) {
+		o.writePolicy = writePolicy
+	}
+}
+
+// Logger is implemented by *log.Logger and most structured loggers
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...any) {}
+
+// Set the logger used to report file operations and errors
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// Record changes in an append-only journal next to the file instead of
+// rewriting the whole file on every change. The journal is replayed when the
+// file is read and compacted into the file after compactAfter entries, or
+// after 1000 entries when compactAfter is not positive. Readers and writers
+// without the option replay an existing journal too, and writers compact it
+// into the file on their next change. Changed records are stored in the
+// format of the file.
+func WithJournal(compactAfter int) Option {
+	return func(o *options) {
+		if compactAfter <= 0 {
+			compactAfter = 1000
+		}
+		o.journal = true
+		o.compactAfter = compactAfter
+	}
+}
+
+// Set when changes are written to disk, see FlushPolicy
+func WithFlushPolicy(flushPolicy FlushPolicy) Option {
+	return func(o *options) {
+		o.flushPolicy = flushPolicy
+	}
+}
+
+// Report records with duplicate IDs in the file to handler instead of failing
+// to read the file. Only the last record with each ID is kept.
+func WithDuplicateIDHandler(handler func(error)) Option {
+	return func(o *options) {
+		o.duplicateIDHandler = handler
+	}
+}
+
+// Hold an advisory lock on a lock file next to the file while changing it, so
+// that several processes can write the same file. The file is reloaded before
+// a change when another process has changed it. On filesystems without OS
+// files, such as MemMapFs, the lock only applies within this process.
+func WithFileLock() Option {
+	return func(o *options) {
+		o.fileLock = true
+	}
+}
+
+// Poll the file for changes every interval and reload it when it changes.
+// handler, if not nil, is called with nil after every successful reload and
+// with the error when the changed file cannot be read, in which case the
+// last good records are kept.
+func WithWatch(interval time.Duration, handler func(error)) Option {
+	return func(o *options) {
+		o.watchInterval = interval
+		o.watchHandler = handler
+	}
+}
@@ -0,0 +1,92 @@
+package gofilestorer
+
+import (
+	"bytes"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestOptions(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	clock := &testClock{now: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}
+	var logs bytes.Buffer
+	logger := log.New(&logs, "", 0)
+
+	// Existing positional arguments keep working without options
+	s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", nil,
+		WithIDFunc(func(dataArray []*testJSONDataInt64, _ *testJSONDataInt64) int64 {
+			return int64(len(dataArray) + 100)
+		}),
+		WithClock(clock),
+		WithFileMode(0600),
+	)
+	assert.NoError(t, err)
+
+	// ID func and clock
+	data := &testJSONDataInt64{Name: "new"}
+	_, err = s.Create(data)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(101), data.ID)
+	assert.Equal(t, clock.now, data.CreatedAt)
+
+	clock.now = clock.now.Add(time.Hour)
+	_, err = s.Update(data.ID, data)
+	assert.NoError(t, err)
+	assert.Equal(t, clock.now, *data.UpdatedAt)
+
+	// File mode is used for new files, existing files keep theirs
+	info, err := fs.Stat("./int64.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "-rw-r--r--", info.Mode().Perm().String())
+
+	_, err = NewJSONWriter[int64, *testJSONDataInt64](fs, "./created.json", nil, WithCreateIfMissing(), WithFileMode(0600))
+	assert.NoError(t, err)
+	info, err = fs.Stat("./created.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "-rw-------", info.Mode().Perm().String())
+
+	// Direct write policy
+	w, err := NewJSONWriter[string, *testJSONDataString](fs, "./string.json", nil,
+		WithIDFunc(func(_ []*testJSONDataString, data *testJSONDataString) string { return data.ID }),
+		WithWritePolicy(WriteDirect),
+	)
+	assert.NoError(t, err)
+	_, err = w.Create(&testJSONDataString{ID: "direct"})
+	assert.NoError(t, err)
+	w, err = NewJSONWriter[string, *testJSONDataString](fs, "./string.json", nil)
+	assert.NoError(t, err)
+	_, err = w.ReadOne("direct")
+	assert.NoError(t, err)
+
+	// ID func with mismatched types
+	_, err = NewJSONWriter[uuid.UUID, *testJSONDataUUID](fs, "./uuid.json", nil,
+		WithIDFunc(func(_ []*testJSONDataInt64, _ *testJSONDataInt64) int64 { return 0 }),
+	)
+	assert.ErrorIs(t, err, ErrorInvalidOption)
+
+	// Readers accept options too
+	r, err := NewJSONReader[uuid.UUID, *testJSONDataUUID](fs, "./missing.json", WithCreateIfMissing(), WithLogger(logger))
+	assert.NoError(t, err)
+	read, err := r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 0)
+	assert.Contains(t, logs.String(), "created missing file ./missing.json")
+
+	c, err := NewCSVReader[uuid.UUID, *testCSVData](afero.NewMemMapFs(), "./missing.csv", ';', WithCreateIfMissing())
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+}
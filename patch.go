@@ -0,0 +1,110 @@
+package gofilestorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// change the stored record with the given id by calling fn on a copy of it
+// under the write lock, and write the changed record to file. The record is
+// left untouched when fn returns an error. The copy is made with Clone when V
+// implements Cloner and through the format of the file otherwise, so fields
+// that are not written to the file start out empty.
+func (s *fileWriter[K, V]) Patch(id K, fn func(V) error) (V, error) {
+	return s.PatchContext(context.Background(), id, fn)
+}
+
+// change a record like Patch, giving up when ctx is done before the record is
+// changed
+func (s *fileWriter[K, V]) PatchContext(ctx context.Context, id K, fn func(V) error) (V, error) {
+	return s.patch(ctx, id, func(stored V) (V, error) {
+		data, err := s.copyRecord(stored)
+		if err != nil {
+			return *new(V), err
+		}
+		if err := fn(data); err != nil {
+			return *new(V), err
+		}
+		return data, nil
+	})
+}
+
+// replace the stored record with the given id by the record returned by fn
+// under the write lock
+func (s *fileWriter[K, V]) patch(ctx context.Context, id K, fn func(V) (V, error)) (V, error) {
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return *new(V), err
+	}
+	defer unlock()
+
+	stored, ok := s.dataMap[id]
+	if !ok || !s.visible(stored) {
+		return *new(V), ErrorDataNotExists
+	}
+	data, err := fn(stored)
+	if err != nil {
+		return *new(V), err
+	}
+
+	c, err := s.update(&s.records, id, data)
+	if err != nil {
+		return *new(V), err
+	}
+	if err := s.persist(c); err != nil {
+		return *new(V), err
+	}
+
+	return s.handBack(data, c), nil
+}
+
+// apply a JSON Merge Patch (RFC 7386) to the JSON encoding of a record,
+// returning the patched record
+func mergePatchRecord[V any](data V, patch []byte) (V, error) {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return *new(V), fmt.Errorf("error unmarshaling merge patch: %w", err)
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return *new(V), fmt.Errorf("error marshaling record: %w", err)
+	}
+	var target any
+	if err := json.Unmarshal(dataBytes, &target); err != nil {
+		return *new(V), fmt.Errorf("error unmarshaling record: %w", err)
+	}
+
+	dataBytes, err = json.Marshal(mergePatch(target, p))
+	if err != nil {
+		return *new(V), fmt.Errorf("error marshaling patched record: %w", err)
+	}
+	patched := newRecord[V]()
+	if err := json.Unmarshal(dataBytes, &patched); err != nil {
+		return *new(V), fmt.Errorf("error unmarshaling patched record: %w", err)
+	}
+	return patched, nil
+}
+
+// merge patch into target as described by RFC 7386: objects are merged
+// recursively, null members are removed and any other value replaces target
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], value)
+		}
+	}
+	return t
+}
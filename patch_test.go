@@ -0,0 +1,133 @@
+package gofilestorer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterPatch(t *testing.T) {
+	fs := getJSONFilesystem(t)
+	s, err := NewJSONWriter[string, *testJSONDataString](fs, "string.json", nil)
+	assert.NoError(t, err)
+
+	// Patch
+	data, err := s.Patch("foobar", func(d *testJSONDataString) error {
+		d.Name = "patched"
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "patched", data.Name)
+	assert.NotNil(t, data.UpdatedAt)
+
+	read, err := s.ReadOne("foobar")
+	assert.NoError(t, err)
+	assert.Equal(t, "patched", read.Name)
+
+	// A failed patch leaves the record untouched
+	errPatch := errors.New("patch failed")
+	_, err = s.Patch("foobar", func(d *testJSONDataString) error {
+		d.Name = "failed"
+		return errPatch
+	})
+	assert.ErrorIs(t, err, errPatch)
+	_, err = s.Patch("foobar", func(d *testJSONDataString) error {
+		d.ID = "other"
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrorIDMismatch)
+
+	read, err = s.ReadOne("foobar")
+	assert.NoError(t, err)
+	assert.Equal(t, "patched", read.Name)
+
+	_, err = s.Patch("missing", func(d *testJSONDataString) error { return nil })
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+}
+
+// testYAMLDataSecret has a field that cannot be written as JSON
+type testYAMLDataSecret struct {
+	testYAMLData `yaml:",inline"`
+	Secret       string `yaml:"secret" json:"-"`
+}
+
+func TestYAMLWriterPatch(t *testing.T) {
+	newIdFunc := func(_ []*testYAMLDataSecret, _ *testYAMLDataSecret) uuid.UUID {
+		return uuid.New()
+	}
+
+	fs := afero.NewMemMapFs()
+	s, err := NewYAMLWriter[uuid.UUID, *testYAMLDataSecret](fs, "./secret.yaml", newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+	data, err := s.Create(&testYAMLDataSecret{testYAMLData: testYAMLData{Name: "new"}, Secret: "secret"})
+	assert.NoError(t, err)
+
+	// The copy keeps the fields written to the file
+	patched, err := s.Patch(data.ID, func(d *testYAMLDataSecret) error {
+		assert.Equal(t, "secret", d.Secret)
+		d.Name = "patched"
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "patched", patched.Name)
+	assert.Equal(t, "secret", patched.Secret)
+}
+
+func TestJSONWriterMergePatch(t *testing.T) {
+	fs := getJSONFilesystem(t)
+	// NewJSONWriter has the signature of the other writer constructors
+	var newWriter func(afero.Fs, string, func([]*testJSONDataString, *testJSONDataString) string, ...Option) (Writer[string, *testJSONDataString], error)
+	newWriter = NewJSONWriter[string, *testJSONDataString]
+
+	w, err := newWriter(fs, "string.json", nil)
+	assert.NoError(t, err)
+	s, ok := w.(JSONWriter[string, *testJSONDataString])
+	assert.True(t, ok)
+
+	// Set a member
+	data, err := s.MergePatch("foobar", []byte(`{"name": "merged"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "merged", data.Name)
+	assert.Equal(t, "foobar", data.ID)
+	assert.NotNil(t, data.UpdatedAt)
+
+	// Remove a member
+	data, err = s.MergePatch("foobar", []byte(`{"name": null}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "", data.Name)
+
+	r, err := NewJSONReader[string, *testJSONDataString](fs, "string.json")
+	assert.NoError(t, err)
+	read, err := r.ReadOne("foobar")
+	assert.NoError(t, err)
+	assert.Equal(t, "", read.Name)
+	assert.False(t, read.CreatedAt.IsZero())
+
+	// Invalid patches
+	_, err = s.MergePatch("foobar", []byte(`{`))
+	assert.Error(t, err)
+	_, err = s.MergePatch("foobar", []byte(`{"id": "other"}`))
+	assert.ErrorIs(t, err, ErrorIDMismatch)
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7386
+	for _, tc := range []struct {
+		target, patch, result any
+	}{
+		{map[string]any{"a": "b"}, map[string]any{"a": "c"}, map[string]any{"a": "c"}},
+		{map[string]any{"a": "b"}, map[string]any{"b": "c"}, map[string]any{"a": "b", "b": "c"}},
+		{map[string]any{"a": "b"}, map[string]any{"a": nil}, map[string]any{}},
+		{map[string]any{"a": []any{"b"}}, map[string]any{"a": "c"}, map[string]any{"a": "c"}},
+		{map[string]any{"a": "c"}, map[string]any{"a": []any{"b"}}, map[string]any{"a": []any{"b"}}},
+		{map[string]any{"a": map[string]any{"b": "c"}}, map[string]any{"a": map[string]any{"b": "d", "c": nil}}, map[string]any{"a": map[string]any{"b": "d"}}},
+		{[]any{"a", "b"}, []any{"c", "d"}, []any{"c", "d"}},
+		{map[string]any{"a": "foo"}, "bar", "bar"},
+		{"foo", map[string]any{"a": map[string]any{"bb": map[string]any{"ccc": nil}}}, map[string]any{"a": map[string]any{"bb": map[string]any{}}}},
+	} {
+		assert.Equal(t, tc.result, mergePatch(tc.target, tc.patch))
+	}
+}
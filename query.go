@@ -0,0 +1,159 @@
+package gofilestorer
+
+import (
+	"context"
+	"sort"
+)
+
+// Query filters, sorts and paginates the records of a storer. Queries are
+// immutable, every method returns a new query, and are evaluated under the
+// read lock of the storer when All, First or Count is called.
+type Query[K comparable, V reader[K]] struct {
+	s       *storer[K, V]
+	filters []func(V) bool
+	less    func(a, b V) bool
+	offset  int
+	limit   int
+}
+
+// start a query over all records in the storer
+func (s *storer[K, V]) Query() Query[K, V] {
+	return Query[K, V]{s: s}
+}
+
+// read all records matching filter
+func (s *storer[K, V]) Find(filter func(V) bool) ([]V, error) {
+	return s.FindContext(context.Background(), filter)
+}
+
+// read all records matching filter, giving up when ctx is done before the
+// storer can be read
+func (s *storer[K, V]) FindContext(ctx context.Context, filter func(V) bool) ([]V, error) {
+	return s.Query().Where(filter).AllContext(ctx)
+}
+
+// only match records for which filter returns true, in addition to any
+// earlier filters
+func (q Query[K, V]) Where(filter func(V) bool) Query[K, V] {
+	q.filters = append(q.filters[:len(q.filters):len(q.filters)], filter)
+	return q
+}
+
+// sort matching records by less, keeping file order for equal records
+func (q Query[K, V]) Sort(less func(a, b V) bool) Query[K, V] {
+	q.less = less
+	return q
+}
+
+// skip the first n matching records
+func (q Query[K, V]) Offset(n int) Query[K, V] {
+	q.offset = n
+	return q
+}
+
+// return at most n matching records
+func (q Query[K, V]) Limit(n int) Query[K, V] {
+	q.limit = n
+	return q
+}
+
+// read the matching records after sorting and pagination
+func (q Query[K, V]) All() ([]V, error) {
+	return q.AllContext(context.Background())
+}
+
+// read the matching records after sorting and pagination, giving up when ctx
+// is done before the storer can be read
+func (q Query[K, V]) AllContext(ctx context.Context) ([]V, error) {
+	if err := q.s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer q.s.mutex.RUnlock()
+
+	return q.s.cloneAll(q.paginate(q.match()))
+}
+
+// read the first matching record after sorting and pagination
+func (q Query[K, V]) First() (V, error) {
+	return q.FirstContext(context.Background())
+}
+
+// read the first matching record after sorting and pagination, giving up when
+// ctx is done before the storer can be read
+func (q Query[K, V]) FirstContext(ctx context.Context) (V, error) {
+	data, err := q.Limit(1).AllContext(ctx)
+	if err != nil {
+		return *new(V), err
+	}
+	if len(data) == 0 {
+		return *new(V), ErrorDataNotExists
+	}
+	return data[0], nil
+}
+
+// count the matching records, ignoring Offset and Limit
+func (q Query[K, V]) Count() (int, error) {
+	return q.CountContext(context.Background())
+}
+
+// count the matching records, ignoring Offset and Limit, giving up when ctx
+// is done before the storer can be read
+func (q Query[K, V]) CountContext(ctx context.Context) (int, error) {
+	if err := q.s.rlock(ctx); err != nil {
+		return 0, err
+	}
+	defer q.s.mutex.RUnlock()
+
+	count := 0
+	for _, record := range q.s.data {
+		if q.matches(record) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// collect the matching records into a new slice and sort it
+func (q Query[K, V]) match() []V {
+	data := []V{}
+	for _, record := range q.s.data {
+		if q.matches(record) {
+			data = append(data, record)
+		}
+	}
+
+	if q.less != nil {
+		sort.SliceStable(data, func(i, j int) bool {
+			return q.less(data[i], data[j])
+		})
+	}
+
+	return data
+}
+
+// report whether the record is visible and passes all filters
+func (q Query[K, V]) matches(record V) bool {
+	if !q.s.visible(record) {
+		return false
+	}
+	for _, filter := range q.filters {
+		if !filter(record) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply offset and limit to the matching records
+func (q Query[K, V]) paginate(data []V) []V {
+	if q.offset > 0 {
+		if q.offset >= len(data) {
+			return []V{}
+		}
+		data = data[q.offset:]
+	}
+	if q.limit > 0 && q.limit < len(data) {
+		data = data[:q.limit]
+	}
+	return data
+}
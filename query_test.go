@@ -0,0 +1,83 @@
+package gofilestorer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReaderQuery(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataInt64, _ *testJSONDataInt64) int64 {
+		return int64(len(dataArray) + 1)
+	}
+
+	s, err := NewJSONWriter[int64, *testJSONDataInt64](fs, "./int64.json", newIdFunc)
+	assert.NoError(t, err)
+	for _, name := range []string{"delta", "alpha", "charlie", "bravo"} {
+		_, err = s.Create(&testJSONDataInt64{Name: name})
+		assert.NoError(t, err)
+	}
+
+	byName := func(a, b *testJSONDataInt64) bool {
+		return a.Name < b.Name
+	}
+	notFoobar := func(d *testJSONDataInt64) bool {
+		return d.Name != "Foobar"
+	}
+
+	// Find
+	read, err := s.Find(func(d *testJSONDataInt64) bool {
+		return d.ID%2 == 0
+	})
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "delta", read[0].Name)
+	assert.Equal(t, "charlie", read[1].Name)
+
+	// Sort
+	read, err = s.Query().Where(notFoobar).Sort(byName).All()
+	assert.NoError(t, err)
+	assert.Len(t, read, 4)
+	assert.Equal(t, "alpha", read[0].Name)
+	assert.Equal(t, "delta", read[3].Name)
+
+	// Offset and Limit
+	q := s.Query().Where(notFoobar).Sort(byName)
+	read, err = q.Offset(1).Limit(2).All()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "bravo", read[0].Name)
+	assert.Equal(t, "charlie", read[1].Name)
+
+	read, err = q.Offset(10).All()
+	assert.NoError(t, err)
+	assert.Len(t, read, 0)
+
+	// Count ignores pagination
+	count, err := q.Limit(1).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	// Where clauses are combined
+	count, err = q.Where(func(d *testJSONDataInt64) bool { return d.ID > 3 }).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// First
+	first, err := q.First()
+	assert.NoError(t, err)
+	assert.Equal(t, "alpha", first.Name)
+
+	_, err = q.Where(func(d *testJSONDataInt64) bool { return false }).First()
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+
+	// Results are new slices
+	read, err = s.Query().All()
+	assert.NoError(t, err)
+	read[0] = nil
+	all, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.NotNil(t, all[0])
+}
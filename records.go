@@ -0,0 +1,93 @@
+package gofilestorer
+
+// records holds the records of a storer as a slice in file order and as a map
+// by ID
+type records[K comparable, V reader[K]] struct {
+	data    []V
+	dataMap map[K]V
+	indexes map[string]*index[K, V]
+}
+
+// copy the slice and map so that changes to the copy do not affect r
+func (r *records[K, V]) copy() records[K, V] {
+	c := records[K, V]{
+		data:    make([]V, len(r.data)),
+		dataMap: make(map[K]V, len(r.dataMap)),
+		indexes: make(map[string]*index[K, V], len(r.indexes)),
+	}
+	copy(c.data, r.data)
+	for id, data := range r.dataMap {
+		c.dataMap[id] = data
+	}
+	for name, idx := range r.indexes {
+		c.indexes[name] = idx.copy()
+	}
+	return c
+}
+
+// append a record
+func (r *records[K, V]) insert(data V) {
+	r.data = append(r.data, data)
+	r.dataMap[data.GetID()] = data
+	r.addToIndexes(data.GetID(), data)
+}
+
+// replace the record with the given id, appending it when it does not exist
+func (r *records[K, V]) replace(id K, data V) {
+	if _, ok := r.dataMap[id]; !ok {
+		r.insert(data)
+		return
+	}
+
+	r.dataMap[id] = data
+	r.removeFromIndexes(id)
+	r.addToIndexes(id, data)
+	for i, d := range r.data {
+		if d.GetID() == id {
+			r.data[i] = data
+			break
+		}
+	}
+}
+
+// remove the record with the given id if it exists, returning its position
+// in file order or -1
+func (r *records[K, V]) remove(id K) int {
+	if _, ok := r.dataMap[id]; !ok {
+		return -1
+	}
+
+	delete(r.dataMap, id)
+	r.removeFromIndexes(id)
+	for i, d := range r.data {
+		if d.GetID() == id {
+			r.data = append(r.data[:i], r.data[i+1:]...)
+			return i
+		}
+	}
+	return -1
+}
+
+// insert a record at the given position in file order
+func (r *records[K, V]) insertAt(i int, data V) {
+	if i < 0 || i > len(r.data) {
+		i = len(r.data)
+	}
+	r.data = append(r.data, data)
+	copy(r.data[i+1:], r.data[i:])
+	r.data[i] = data
+	r.dataMap[data.GetID()] = data
+	r.addToIndexes(data.GetID(), data)
+}
+
+// undo a change that was applied to the records
+func (r *records[K, V]) revert(c change[K, V]) {
+	switch c.op {
+	case opCreate:
+		r.remove(c.id)
+	case opUpdate:
+		r.replace(c.id, c.old)
+	case opDelete:
+		r.insertAt(c.index, c.old)
+	}
+}
@@ -0,0 +1,169 @@
+package gofilestorer
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTestWriteFailed = errors.New("write failed")
+
+// failingFs fails all writes while fail is set
+type failingFs struct {
+	afero.Fs
+	fail bool
+}
+
+func (fs *failingFs) Create(name string) (afero.File, error) {
+	if fs.fail {
+		return nil, errTestWriteFailed
+	}
+	return fs.Fs.Create(name)
+}
+
+func (fs *failingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if fs.fail && flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		return nil, errTestWriteFailed
+	}
+	return fs.Fs.OpenFile(name, flag, perm)
+}
+
+func (fs *failingFs) Rename(oldName, newName string) error {
+	if fs.fail {
+		return errTestWriteFailed
+	}
+	return fs.Fs.Rename(oldName, newName)
+}
+
+func TestWriterRollback(t *testing.T) {
+	newIdFunc := func(_ []*testCSVData, _ *testCSVData) uuid.UUID {
+		return uuid.New()
+	}
+
+	for name, opts := range map[string][]Option{
+		"Atomic":  nil,
+		"Direct":  {WithWritePolicy(WriteDirect)},
+		"Journal": {WithJournal(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for backend, open := range map[string]func(afero.Fs) (Writer[uuid.UUID, *testCSVData], error){
+				"JSON": func(fs afero.Fs) (Writer[uuid.UUID, *testCSVData], error) {
+					opts := append([]Option{WithCreateIfMissing(), WithIndex("name", func(d *testCSVData) any { return d.Name })}, opts...)
+					return NewJSONWriter[uuid.UUID, *testCSVData](fs, "rollback.json", newIdFunc, opts...)
+				},
+				"CSV": func(fs afero.Fs) (Writer[uuid.UUID, *testCSVData], error) {
+					opts := append([]Option{WithCreateIfMissing(), WithIndex("name", func(d *testCSVData) any { return d.Name })}, opts...)
+					return NewCSVWriter[uuid.UUID, *testCSVData](fs, "rollback.csv", ',', newIdFunc, opts...)
+				},
+			} {
+				t.Run(backend, func(t *testing.T) {
+					fs := &failingFs{Fs: afero.NewMemMapFs()}
+					s, err := open(fs)
+					assert.NoError(t, err)
+
+					first, err := s.Create(&testCSVData{Name: "first"})
+					assert.NoError(t, err)
+					second, err := s.Create(&testCSVData{Name: "second"})
+					assert.NoError(t, err)
+					before, err := s.ReadAll()
+					assert.NoError(t, err)
+
+					fs.fail = true
+
+					// Create
+					_, err = s.Create(&testCSVData{Name: "failed"})
+					assert.ErrorIs(t, err, errTestWriteFailed)
+					read, err := s.ReadBy("name", "failed")
+					assert.NoError(t, err)
+					assert.Empty(t, read)
+
+					// Update
+					_, err = s.Update(first.ID, &testCSVData{Name: "failed"})
+					assert.ErrorIs(t, err, errTestWriteFailed)
+					data, err := s.ReadOne(first.ID)
+					assert.NoError(t, err)
+					assert.Equal(t, "first", data.Name)
+
+					// Delete keeps the position of the record
+					err = s.Delete(first.ID)
+					assert.ErrorIs(t, err, errTestWriteFailed)
+
+					// Bulk
+					_, err = s.CreateMany([]*testCSVData{{Name: "failed"}})
+					assert.ErrorIs(t, err, errTestWriteFailed)
+
+					after, err := s.ReadAll()
+					assert.NoError(t, err)
+					assert.Equal(t, before, after)
+					read, err = s.ReadBy("name", "first")
+					assert.NoError(t, err)
+					assert.Len(t, read, 1)
+
+					// Changes succeed again once writes work and only they are written
+					fs.fail = false
+					err = s.Delete(second.ID)
+					assert.NoError(t, err)
+
+					s, err = open(fs)
+					assert.NoError(t, err)
+					read, err = s.ReadAll()
+					assert.NoError(t, err)
+					assert.Len(t, read, 1)
+					assert.Equal(t, first.ID, read[0].ID)
+					assert.Equal(t, "first", read[0].Name)
+				})
+			}
+		})
+	}
+}
+
+func TestWriterRollbackAliased(t *testing.T) {
+	newIdFunc := func(_ []*testJSONDataVersioned, data *testJSONDataVersioned) string {
+		return data.ID
+	}
+
+	fs := &failingFs{Fs: afero.NewMemMapFs()}
+	s, err := NewJSONWriter[string, *testJSONDataVersioned](fs, "rollback.json", newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+
+	_, err = s.Create(&testJSONDataVersioned{testJSONDataString: testJSONDataString{ID: "new", Name: "new"}})
+	assert.NoError(t, err)
+
+	// Update the stored record itself while writes fail
+	fs.fail = true
+	data, err := s.ReadOne("new")
+	assert.NoError(t, err)
+	_, err = s.Update("new", data)
+	assert.ErrorIs(t, err, errTestWriteFailed)
+
+	// The version of the stored record matches the file again
+	read, err := s.ReadOne("new")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), read.Version)
+	assert.Nil(t, read.UpdatedAt)
+
+	// The same holds for batches and transactions
+	_, err = s.UpdateMany([]*testJSONDataVersioned{read})
+	assert.ErrorIs(t, err, errTestWriteFailed)
+	assert.Equal(t, int64(1), read.Version)
+	assert.Nil(t, read.UpdatedAt)
+
+	tx, err := s.Begin()
+	assert.NoError(t, err)
+	_, err = tx.Update("new", read)
+	assert.NoError(t, err)
+	err = tx.Commit()
+	assert.ErrorIs(t, err, errTestWriteFailed)
+	read, err = s.ReadOne("new")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), read.Version)
+	assert.Nil(t, read.UpdatedAt)
+
+	fs.fail = false
+	err = s.DeleteIfVersion("new", 1)
+	assert.NoError(t, err)
+}
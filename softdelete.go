@@ -0,0 +1,171 @@
+package gofilestorer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// softDeleter is implemented by records that can be marked as deleted
+// instead of being removed, which WithSoftDelete requires
+type softDeleter interface {
+	GetDeletedAt() time.Time
+	SetDeletedAt(time.Time)
+}
+
+// Mark records as deleted by setting their DeletedAt instead of removing
+// them. Deleted records are hidden from reads and changes, can be read with
+// ReadDeleted, restored with Restore and removed for good with Purge. The
+// record type must implement GetDeletedAt() time.Time and SetDeletedAt(time.Time).
+func WithSoftDelete() Option {
+	return func(o *options) {
+		o.softDelete = true
+	}
+}
+
+// check that the record type of the storer supports soft deletes when they
+// are enabled
+func (s *storer[K, V]) checkSoftDelete() error {
+	if !s.softDelete {
+		return nil
+	}
+	if _, ok := any(*new(V)).(softDeleter); !ok {
+		return fmt.Errorf("%w: soft delete requires %T to implement GetDeletedAt and SetDeletedAt", ErrorInvalidOption, *new(V))
+	}
+	return nil
+}
+
+// report whether the record is marked as deleted
+func isDeleted[V any](data V) bool {
+	d, ok := any(data).(softDeleter)
+	return ok && !d.GetDeletedAt().IsZero()
+}
+
+// report whether the record is visible to reads and changes, which is every
+// record unless it is soft deleted
+func (s *storer[K, V]) visible(data V) bool {
+	return !s.softDelete || !isDeleted(data)
+}
+
+// collect the visible records
+func (s *storer[K, V]) visibleRecords(data []V) []V {
+	if !s.softDelete {
+		return data
+	}
+
+	visible := []V{}
+	for _, record := range data {
+		if !isDeleted(record) {
+			visible = append(visible, record)
+		}
+	}
+	return visible
+}
+
+// read all soft deleted records
+func (s *storer[K, V]) ReadDeleted() ([]V, error) {
+	return s.ReadDeletedContext(context.Background())
+}
+
+// read all soft deleted records, giving up when ctx is done before the storer
+// can be read
+func (s *storer[K, V]) ReadDeletedContext(ctx context.Context) ([]V, error) {
+	if !s.softDelete {
+		return nil, ErrorSoftDeleteDisabled
+	}
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mutex.RUnlock()
+
+	deleted := []V{}
+	for _, record := range s.data {
+		if isDeleted(record) {
+			deleted = append(deleted, record)
+		}
+	}
+	return s.cloneAll(deleted)
+}
+
+// apply a soft delete of the stored record old to r. Only the fields set
+// through the record interfaces change, so a shallow copy of old is marked.
+func (s *fileWriter[K, V]) markDeleted(r *records[K, V], id K, old V) (change[K, V], error) {
+	data := shallowCopy(old)
+	any(data).(softDeleter).SetDeletedAt(s.now())
+	data.SetUpdatedAt(s.now())
+	setVersion(&old, data)
+	r.replace(id, data)
+
+	return change[K, V]{op: opUpdate, id: id, old: old, new: data, event: EventDeleted}, nil
+}
+
+// clear the deletion mark of a soft deleted record and write it to file
+func (s *fileWriter[K, V]) Restore(id K) (V, error) {
+	return s.RestoreContext(context.Background(), id)
+}
+
+// restore a record like Restore, giving up when ctx is done before the record
+// is restored
+func (s *fileWriter[K, V]) RestoreContext(ctx context.Context, id K) (V, error) {
+	if !s.softDelete {
+		return *new(V), ErrorSoftDeleteDisabled
+	}
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return *new(V), err
+	}
+	defer unlock()
+
+	old, ok := s.dataMap[id]
+	if !ok {
+		return *new(V), ErrorDataNotExists
+	}
+	if !isDeleted(old) {
+		return *new(V), ErrorNotDeleted
+	}
+
+	data := shallowCopy(old)
+	any(data).(softDeleter).SetDeletedAt(time.Time{})
+	data.SetUpdatedAt(s.now())
+	setVersion(&old, data)
+	s.replace(id, data)
+
+	c := change[K, V]{op: opUpdate, id: id, old: old, new: data, event: EventRestored}
+	if err := s.persist(c); err != nil {
+		return *new(V), err
+	}
+	return s.clone(data)
+}
+
+// remove the records that were soft deleted at least olderThan ago from the
+// file for good, returning the number of removed records
+func (s *fileWriter[K, V]) Purge(olderThan time.Duration) (int, error) {
+	return s.PurgeContext(context.Background(), olderThan)
+}
+
+// remove soft deleted records like Purge, giving up when ctx is done before
+// the records are removed
+func (s *fileWriter[K, V]) PurgeContext(ctx context.Context, olderThan time.Duration) (int, error) {
+	if !s.softDelete {
+		return 0, ErrorSoftDeleteDisabled
+	}
+
+	purged := 0
+	err := s.applyBatch(ctx, func(r *records[K, V]) ([]change[K, V], error) {
+		cutoff := s.now().Add(-olderThan)
+		changes := []change[K, V]{}
+		for _, record := range append([]V(nil), r.data...) {
+			if !isDeleted(record) || any(record).(softDeleter).GetDeletedAt().After(cutoff) {
+				continue
+			}
+			r.remove(record.GetID())
+			changes = append(changes, change[K, V]{op: opDelete, id: record.GetID(), old: record, event: EventPurged})
+		}
+		purged = len(changes)
+		return changes, nil
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
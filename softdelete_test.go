@@ -0,0 +1,152 @@
+package gofilestorer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+type testJSONDataSoftDelete struct {
+	testJSONDataString
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (d *testJSONDataSoftDelete) GetDeletedAt() time.Time {
+	return d.DeletedAt
+}
+
+func (d *testJSONDataSoftDelete) SetDeletedAt(deletedAt time.Time) {
+	d.DeletedAt = deletedAt
+}
+
+// testYAMLDataSoftDelete has a field that cannot be written as JSON
+type testYAMLDataSoftDelete struct {
+	testYAMLData `yaml:",inline"`
+	DeletedAt    time.Time `yaml:"deleted_at"`
+	Secret       string    `yaml:"secret" json:"-"`
+}
+
+func (d *testYAMLDataSoftDelete) GetDeletedAt() time.Time {
+	return d.DeletedAt
+}
+
+func (d *testYAMLDataSoftDelete) SetDeletedAt(deletedAt time.Time) {
+	d.DeletedAt = deletedAt
+}
+
+func TestYAMLWriterSoftDelete(t *testing.T) {
+	newIdFunc := func(_ []*testYAMLDataSoftDelete, _ *testYAMLDataSoftDelete) uuid.UUID {
+		return uuid.New()
+	}
+
+	fs := afero.NewMemMapFs()
+	s, err := NewYAMLWriter[uuid.UUID, *testYAMLDataSoftDelete](fs, "./deleted.yaml", newIdFunc, WithCreateIfMissing(), WithSoftDelete())
+	assert.NoError(t, err)
+	data, err := s.Create(&testYAMLDataSoftDelete{testYAMLData: testYAMLData{Name: "new"}, Secret: "secret"})
+	assert.NoError(t, err)
+
+	// Deleting and restoring keep the other fields of the record
+	err = s.Delete(data.ID)
+	assert.NoError(t, err)
+	deleted, err := s.ReadDeleted()
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, "secret", deleted[0].Secret)
+	assert.True(t, data.DeletedAt.IsZero())
+
+	restored, err := s.Restore(data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", restored.Secret)
+	assert.True(t, restored.DeletedAt.IsZero())
+}
+
+func TestWriterSoftDelete(t *testing.T) {
+	fs := getJSONFilesystem(t)
+	clock := &testClock{now: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}
+	newIdFunc := func(dataArray []*testJSONDataSoftDelete, _ *testJSONDataSoftDelete) string {
+		return "new"
+	}
+
+	// The record type must support soft deletes
+	_, err := NewJSONWriter[string, *testJSONDataString](fs, "string.json", nil, WithSoftDelete())
+	assert.ErrorIs(t, err, ErrorInvalidOption)
+
+	s, err := NewJSONWriter[string, *testJSONDataSoftDelete](fs, "string.json", newIdFunc, WithSoftDelete(), WithClock(clock))
+	assert.NoError(t, err)
+	_, err = s.Create(&testJSONDataSoftDelete{testJSONDataString: testJSONDataString{Name: "new"}})
+	assert.NoError(t, err)
+
+	// Delete
+	err = s.Delete("foobar")
+	assert.NoError(t, err)
+
+	// Deleted records are hidden
+	_, err = s.ReadOne("foobar")
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	count, err := s.Query().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	_, err = s.Update("foobar", &testJSONDataSoftDelete{})
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+	err = s.Delete("foobar")
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+
+	// Deleted records are kept in the file
+	r, err := NewJSONReader[string, *testJSONDataSoftDelete](fs, "string.json")
+	assert.NoError(t, err)
+	read, err = r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, clock.now, read[0].DeletedAt)
+	assert.Equal(t, clock.now, *read[0].UpdatedAt)
+
+	// Upsert neither updates nor creates a deleted record
+	_, err = s.Upsert(&testJSONDataSoftDelete{testJSONDataString: testJSONDataString{ID: "foobar", Name: "upserted"}})
+	assert.ErrorIs(t, err, ErrorDataDeleted)
+
+	// ReadDeleted
+	deleted, err := s.ReadDeleted()
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, "foobar", deleted[0].ID)
+
+	// Restore
+	restored, err := s.Restore("foobar")
+	assert.NoError(t, err)
+	assert.True(t, restored.DeletedAt.IsZero())
+	_, err = s.ReadOne("foobar")
+	assert.NoError(t, err)
+	_, err = s.Restore("foobar")
+	assert.ErrorIs(t, err, ErrorNotDeleted)
+
+	// Purge only removes records deleted long enough ago
+	err = s.Delete("foobar")
+	assert.NoError(t, err)
+	clock.now = clock.now.Add(time.Hour)
+	err = s.Delete("new")
+	assert.NoError(t, err)
+
+	purged, err := s.Purge(time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	deleted, err = s.ReadDeleted()
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, "new", deleted[0].ID)
+
+	r, err = NewJSONReader[string, *testJSONDataSoftDelete](fs, "string.json")
+	assert.NoError(t, err)
+	read, err = r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+
+	// Without soft deletes
+	_, err = r.ReadDeleted()
+	assert.ErrorIs(t, err, ErrorSoftDeleteDisabled)
+}
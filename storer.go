@@ -1,19 +1,55 @@
 package gofilestorer
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
 	"sync"
 	"time"
 
 	"github.com/spf13/afero"
 )
 
-type storer[K comparable, V any] struct {
+type storer[K comparable, V reader[K]] struct {
 	fs        afero.Fs
 	fileName  string
 	mutex     sync.RWMutex
-	data      []V
-	dataMap   map[K]V
 	newIDFunc func(dataArray []V, data V) K
+	codec     codec[V]
+	records[K, V]
+	options
+
+	journalEntries int
+	journalSize    int64
+
+	pending     []change[K, V]
+	flushErr    error
+	stopFlush   chan struct{}
+	flushDone   chan struct{}
+	closed      bool
+	generation  uint64
+	stamp       [2]fileStamp
+	stopWatch   chan struct{}
+	watchDone   chan struct{}
+	subscribers subscribers[K, V]
+	outbox      []Event[K, V]
+}
+
+// codec converts between the contents of a file and the records of a storer
+type codec[V any] interface {
+	decode(dataBytes []byte) ([]V, error)
+	encode(data []V) ([]byte, error)
+}
+
+// appendCodec is implemented by codecs whose files can be extended with new
+// records by appending their encoding, so that creates do not rewrite the file
+type appendCodec[V any] interface {
+	codec[V]
+	encodeAppend(data []V) ([]byte, error)
+	// report whether a last line without a newline holds a complete record
+	// rather than being left behind by a failed append
+	complete(line []byte) bool
 }
 
 type Reader[K comparable, V reader[K]] interface {
@@ -21,33 +57,320 @@ type Reader[K comparable, V reader[K]] interface {
 
 	ReadAll() ([]V, error)
 	ReadOne(K) (V, error)
+	Find(func(V) bool) ([]V, error)
+	ReadBy(string, any) ([]V, error)
+	Reload() error
+	Close() error
+
+	ReadAllContext(context.Context) ([]V, error)
+	ReadOneContext(context.Context, K) (V, error)
+	FindContext(context.Context, func(V) bool) ([]V, error)
+	ReadByContext(context.Context, string, any) ([]V, error)
+	ReloadContext(context.Context) error
+	ReadDeleted() ([]V, error)
+	ReadDeletedContext(context.Context) ([]V, error)
+	Query() Query[K, V]
 }
 
 type reader[K comparable] interface {
 	GetID() K
 }
 
-// read all records from the storer
+// read all records from the storer into a new slice
 func (s *storer[K, V]) ReadAll() ([]V, error) {
-	s.mutex.RLock()
+	return s.ReadAllContext(context.Background())
+}
+
+// read all records from the storer into a new slice, giving up when ctx is
+// done before the storer can be read
+func (s *storer[K, V]) ReadAllContext(ctx context.Context) ([]V, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
 	defer s.mutex.RUnlock()
-	return s.data, nil
+	return s.cloneAll(s.visibleRecords(s.data))
 }
 
 // read a record from the storer
 func (s *storer[K, V]) ReadOne(id K) (V, error) {
-	s.mutex.RLock()
+	return s.ReadOneContext(context.Background(), id)
+}
+
+// read a record from the storer, giving up when ctx is done before the storer
+// can be read
+func (s *storer[K, V]) ReadOneContext(ctx context.Context, id K) (V, error) {
+	if err := s.rlock(ctx); err != nil {
+		return *new(V), err
+	}
 	defer s.mutex.RUnlock()
 
-	_, ok := s.dataMap[id]
-	if ok {
+	data, ok := s.dataMap[id]
+	if ok && s.visible(data) {
 
-		return s.dataMap[id], nil
+		return s.clone(data)
 	}
 
 	return *new(V), ErrorDataNotExists
 }
 
+// apply the options, create the file if requested and read it into the storer
+func (s *storer[K, V]) open() error {
+	if s.idFunc != nil {
+		newIDFunc, ok := s.idFunc.(func([]V, V) K)
+		if !ok {
+			return fmt.Errorf("%w: ID func has type %T", ErrorInvalidOption, s.idFunc)
+		}
+		s.newIDFunc = newIDFunc
+	}
+	if err := s.checkIndexDefs(); err != nil {
+		return err
+	}
+	if err := s.checkSoftDelete(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.createIfMissing {
+		if err := createFileIfMissing(s.fs, s.fileName, s.writeEmptyFile); err != nil {
+			return err
+		}
+	}
+	if err := s.readFile(); err != nil {
+		return err
+	}
+
+	s.startWatch()
+	return nil
+}
+
+// read the file into the storer, leaving the storer untouched on failure
+func (s *storer[K, V]) readFile() error {
+	// Read file from disk
+	dataBytes, err := afero.ReadFile(s.fs, s.fileName)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	// Unmarshal file to struct
+	data, err := s.codec.decode(dataBytes)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling data: %w", err)
+	}
+
+	// Check for duplicate IDs, keeping the last record with an ID when they
+	// are reported instead of rejected
+	last := make(map[K]int, len(data))
+	for i, record := range data {
+		id := record.GetID()
+		if _, ok := last[id]; ok {
+			err := fmt.Errorf("%w: %v", ErrorDuplicateID, id)
+			if s.duplicateIDHandler == nil {
+				return fmt.Errorf("error reading file: %w", err)
+			}
+			s.duplicateIDHandler(err)
+		}
+		last[id] = i
+	}
+	if len(last) < len(data) {
+		deduplicated := make([]V, 0, len(last))
+		for i, record := range data {
+			if last[record.GetID()] == i {
+				deduplicated = append(deduplicated, record)
+			}
+		}
+		data = deduplicated
+	}
+
+	// Create map of data
+	dataMap := map[K]V{}
+	for _, record := range data {
+		dataMap[record.GetID()] = record
+	}
+	r := records[K, V]{data: data, dataMap: dataMap}
+
+	// Build secondary indexes
+	if err := s.buildIndexes(&r); err != nil {
+		return fmt.Errorf("error indexing data: %w", err)
+	}
+
+	// Replay changes made since the last snapshot, also without WithJournal
+	// so that the changes of writers using it are not missed
+	journalEntries, journalSize, err := s.replayJournal(&r)
+	if err != nil {
+		return err
+	}
+
+	s.records = r
+	s.journalEntries, s.journalSize = journalEntries, journalSize
+	s.updateStamp()
+
+	return nil
+}
+
+// encode a single record in the format of the file
+func (s *storer[K, V]) encodeRecord(data V) ([]byte, error) {
+	return s.codec.encode([]V{data})
+}
+
+// decode a single record encoded by encodeRecord
+func (s *storer[K, V]) decodeRecord(dataBytes []byte) (V, error) {
+	data, err := s.codec.decode(dataBytes)
+	if err != nil {
+		return *new(V), err
+	}
+	if len(data) != 1 {
+		return *new(V), fmt.Errorf("expected 1 record, got %d", len(data))
+	}
+	return data[0], nil
+}
+
+// write the file from the storer
+func (s *storer[K, V]) writeFile() error {
+	// Marshal struct to bytes
+	dataBytes, err := s.codec.encode(s.data)
+	if err != nil {
+		return fmt.Errorf("error marshaling data: %w", err)
+	}
+
+	// Write file to disk
+	if s.writePolicy == WriteDirect {
+		err = afero.WriteFile(s.fs, s.fileName, dataBytes, s.fileMode)
+	} else {
+		err = writeFileAtomic(s.fs, s.fileName, dataBytes, s.fileMode)
+	}
+	if err != nil {
+		s.logger.Printf("error writing file %s: %v", s.fileName, err)
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	s.updateStamp()
+
+	return nil
+}
+
+// append the records created by the pending changes to the file when the
+// codec supports it, reporting whether they were appended. Any other change
+// requires the file to be rewritten.
+func (s *storer[K, V]) appendFile() (bool, error) {
+	c, ok := s.codec.(appendCodec[V])
+	if !ok {
+		return false, nil
+	}
+	created := make([]V, 0, len(s.pending))
+	for _, change := range s.pending {
+		if change.op != opCreate {
+			return false, nil
+		}
+		created = append(created, change.new)
+	}
+
+	dataBytes, err := c.encodeAppend(created)
+	if err != nil {
+		return false, fmt.Errorf("error marshaling data: %w", err)
+	}
+	appended, err := appendFile(s.fs, s.fileName, dataBytes, s.fileMode, c.complete)
+	if err != nil {
+		s.logger.Printf("error appending to file %s: %v", s.fileName, err)
+		return false, err
+	}
+	if appended {
+		s.updateStamp()
+	}
+
+	return appended, nil
+}
+
+// write an empty file from the storer
+func (s *storer[K, V]) writeEmptyFile() error {
+	s.data = []V{}
+	if err := s.writeFile(); err != nil {
+		return err
+	}
+	s.logger.Printf("created missing file %s", s.fileName)
+	return nil
+}
+
+type changeOp string
+
+const (
+	opCreate changeOp = "create"
+	opUpdate changeOp = "update"
+	opDelete changeOp = "delete"
+)
+
+// change describes a mutation that has been applied to the storer
+type change[K comparable, V any] struct {
+	op     changeOp
+	id     K
+	old    V
+	new    V
+	result V
+	index  int
+	event  EventType
+}
+
+// persist changes that have already been applied to the storer according to
+// the flush policy. The changes are reverted when writing them fails, so that
+// the storer does not show changes that were reported as failed.
+func (s *storer[K, V]) persist(changes ...change[K, V]) error {
+	if err := s.queue(changes); err != nil {
+		for i := len(changes) - 1; i >= 0; i-- {
+			s.revert(changes[i])
+		}
+		return err
+	}
+
+	return nil
+}
+
+// replace the records of the storer with r, to which changes have been
+// applied, and persist changes in a single batch. The records are restored
+// when persisting fails.
+func (s *storer[K, V]) persistRecords(r records[K, V], changes []change[K, V]) error {
+	previous := s.records
+	s.records = r
+	if err := s.queue(changes); err != nil {
+		s.records = previous
+		return err
+	}
+
+	return nil
+}
+
+// add changes to the pending changes and flush them when the flush policy
+// asks for it. The changes are dropped from the pending changes again when
+// the flush fails, while earlier pending changes are kept for the next flush.
+func (s *storer[K, V]) queue(changes []change[K, V]) error {
+	pending, generation := len(s.pending), s.generation
+	s.generation++
+	s.pending = append(s.pending, changes...)
+	if !s.flushPolicy.due(len(s.pending)) {
+		return nil
+	}
+
+	if err := s.flush(); err != nil {
+		s.pending, s.generation = s.pending[:pending], generation
+		return err
+	}
+	return nil
+}
+
+// write the snapshot file and clear the journal
+func (s *storer[K, V]) compact() error {
+	if err := s.writeFile(); err != nil {
+		return err
+	}
+
+	if err := s.fs.Remove(s.journalFileName()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing journal: %w", err)
+	}
+	s.journalEntries = 0
+	s.journalSize = 0
+	s.updateStamp()
+
+	return nil
+}
+
 type Writer[K comparable, V writer[K]] interface {
 	Reader[K, V]
 	writeFile() error
@@ -55,6 +378,39 @@ type Writer[K comparable, V writer[K]] interface {
 	Create(V) (V, error)
 	Update(K, V) (V, error)
 	Delete(K) error
+	UpdateIfVersion(K, int64, V) (V, error)
+	DeleteIfVersion(K, int64) error
+	Compact() error
+	Flush() error
+	Begin() (Tx[K, V], error)
+	Upsert(V) (V, error)
+	CreateMany([]V) ([]V, error)
+	UpdateMany([]V) ([]V, error)
+	DeleteMany([]K) error
+	DeleteWhere(func(V) bool) (int, error)
+	Patch(K, func(V) error) (V, error)
+	Restore(K) (V, error)
+	Purge(time.Duration) (int, error)
+
+	CreateContext(context.Context, V) (V, error)
+	UpdateContext(context.Context, K, V) (V, error)
+	DeleteContext(context.Context, K) error
+	UpdateIfVersionContext(context.Context, K, int64, V) (V, error)
+	DeleteIfVersionContext(context.Context, K, int64) error
+	CompactContext(context.Context) error
+	FlushContext(context.Context) error
+	BeginContext(context.Context) (Tx[K, V], error)
+	UpsertContext(context.Context, V) (V, error)
+	CreateManyContext(context.Context, []V) ([]V, error)
+	UpdateManyContext(context.Context, []V) ([]V, error)
+	DeleteManyContext(context.Context, []K) error
+	DeleteWhereContext(context.Context, func(V) bool) (int, error)
+	PatchContext(context.Context, K, func(V) error) (V, error)
+	RestoreContext(context.Context, K) (V, error)
+	PurgeContext(context.Context, time.Duration) (int, error)
+
+	Subscribe(...SubscribeOption) (<-chan Event[K, V], func())
+	SubscribeFunc(func(Event[K, V]), ...SubscribeOption) func()
 }
 
 type writer[K comparable] interface {
@@ -63,3 +419,20 @@ type writer[K comparable] interface {
 	SetCreatedAt(time.Time)
 	SetUpdatedAt(time.Time)
 }
+
+// createdAtGetter is implemented by records that expose their creation time,
+// which allows Update to keep it when the caller passes a new record
+type createdAtGetter interface {
+	GetCreatedAt() time.Time
+}
+
+// allocate a new record of type V, allocating the underlying struct when V
+// is a pointer type
+func newRecord[V any]() V {
+	var v V
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		return reflect.New(t.Elem()).Interface().(V)
+	}
+	return v
+}
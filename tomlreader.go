@@ -0,0 +1,49 @@
+package gofilestorer
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+)
+
+type tomlReader[K comparable, V reader[K]] struct {
+	storer[K, V]
+}
+
+// Create a new reader that is backed by a TOML file
+func NewTOMLReader[K comparable, V reader[K]](fs afero.Fs, fileName string, opts ...Option) (Reader[K, V], error) {
+	s := &tomlReader[K, V]{
+		storer: storer[K, V]{
+			fs:       fs,
+			fileName: fileName,
+			codec:    tomlCodec[V]{},
+			options:  newOptions(opts),
+		},
+	}
+
+	// Read file
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// tomlFile is the layout of a TOML file, which stores the records as an
+// array of tables named records
+type tomlFile[V any] struct {
+	Records []V `toml:"records"`
+}
+
+type tomlCodec[V any] struct{}
+
+// unmarshal the records array of tables into records
+func (tomlCodec[V]) decode(dataBytes []byte) ([]V, error) {
+	file := tomlFile[V]{}
+	if err := toml.Unmarshal(dataBytes, &file); err != nil {
+		return nil, err
+	}
+	if file.Records == nil {
+		file.Records = []V{}
+	}
+	return file.Records, nil
+}
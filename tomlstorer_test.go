@@ -0,0 +1,139 @@
+package gofilestorer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTOMLFilesystem(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	// create test files and directories
+	err := fs.MkdirAll("data", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "string.toml", []byte(`# reference data
+[[records]]
+id = "foo"
+created_at = 2022-12-27T12:45:51.8347046-08:00
+name = "Foo"
+
+[[records]]
+id = "bar"
+created_at = 2022-12-28T12:45:51.8347046-08:00
+name = "Bar"
+`), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "data/invalid.toml", []byte(`[[records]]
+id = `), 0644)
+	assert.NoError(t, err)
+
+	return fs
+}
+
+type testTOMLData struct {
+	ID        string     `toml:"id"`
+	CreatedAt time.Time  `toml:"created_at"`
+	UpdatedAt *time.Time `toml:"updated_at,omitempty"`
+	Name      string     `toml:"name"`
+}
+
+func (d *testTOMLData) GetID() string {
+	return d.ID
+}
+
+func (d *testTOMLData) SetID(id string) {
+	d.ID = id
+}
+
+func (d *testTOMLData) GetCreatedAt() time.Time {
+	return d.CreatedAt
+}
+
+func (d *testTOMLData) SetCreatedAt(createdAt time.Time) {
+	d.CreatedAt = createdAt
+}
+
+func (d *testTOMLData) SetUpdatedAt(updatedAt time.Time) {
+	d.UpdatedAt = &updatedAt
+}
+
+func TestTOMLReader(t *testing.T) {
+	fs := getTOMLFilesystem(t)
+
+	// Read non-existant file
+	s, err := NewTOMLReader[string, *testTOMLData](fs, "./foobar.toml")
+	assert.Error(t, err)
+	assert.Nil(t, s)
+
+	// Read invalid file
+	s, err = NewTOMLReader[string, *testTOMLData](fs, "./data/invalid.toml")
+	assert.Error(t, err)
+	assert.Nil(t, s)
+
+	// Read test file
+	s, err = NewTOMLReader[string, *testTOMLData](fs, "./string.toml")
+	assert.NoError(t, err)
+
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "foo", read[0].ID)
+	assert.Equal(t, "Bar", read[1].Name)
+	assert.NotEmpty(t, read[0].CreatedAt)
+}
+
+func TestTOMLWriter(t *testing.T) {
+	fs := getTOMLFilesystem(t)
+
+	newIdFunc := func(_ []*testTOMLData, data *testTOMLData) string {
+		return data.Name
+	}
+
+	s, err := NewTOMLWriter[string, *testTOMLData](fs, "./string.toml", newIdFunc)
+	assert.NoError(t, err)
+
+	// Create
+	data, err := s.Create(&testTOMLData{Name: "baz"})
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", data.ID)
+
+	// Update
+	_, err = s.Update("foo", &testTOMLData{Name: "updated"})
+	assert.NoError(t, err)
+
+	// Delete
+	err = s.Delete("bar")
+	assert.NoError(t, err)
+
+	// The records are stored as an array of tables
+	dataBytes, err := afero.ReadFile(fs, "./string.toml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(dataBytes), "[[records]]\n")
+	assert.Contains(t, string(dataBytes), `id = "foo"`)
+
+	// Reopen
+	r, err := NewTOMLReader[string, *testTOMLData](fs, "./string.toml")
+	assert.NoError(t, err)
+	read, err := r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "updated", read[0].Name)
+	assert.NotNil(t, read[0].UpdatedAt)
+	assert.Equal(t, "baz", read[1].ID)
+	assert.True(t, data.CreatedAt.Equal(read[1].CreatedAt))
+
+	// Create missing file
+	s, err = NewTOMLWriter[string, *testTOMLData](fs, "./missing/data.toml", newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, read)
+
+	r, err = NewTOMLReader[string, *testTOMLData](fs, "./missing/data.toml")
+	assert.NoError(t, err)
+	read, err = r.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, read)
+}
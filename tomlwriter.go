@@ -0,0 +1,43 @@
+package gofilestorer
+
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+)
+
+type tomlWriter[K comparable, V writer[K]] struct {
+	fileWriter[K, V]
+}
+
+// Create a new writer that is backed by a TOML file
+func NewTOMLWriter[K comparable, V writer[K]](fs afero.Fs, fileName string, newIDFunc func([]V, V) K, opts ...Option) (Writer[K, V], error) {
+	s := &tomlWriter[K, V]{
+		fileWriter: fileWriter[K, V]{
+			storer: storer[K, V]{
+				fs:        fs,
+				fileName:  fileName,
+				newIDFunc: newIDFunc,
+				codec:     tomlCodec[V]{},
+				options:   newOptions(opts),
+			},
+		},
+	}
+
+	// Read file
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// marshal the records into an array of tables named records
+func (tomlCodec[V]) encode(data []V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(tomlFile[V]{Records: data}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
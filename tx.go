@@ -0,0 +1,179 @@
+package gofilestorer
+
+import "context"
+
+// Tx stages changes against a copy of the records of a writer. Reads through
+// the transaction see its own changes, and Commit writes all of them at once.
+// A Tx is not safe for concurrent use.
+type Tx[K comparable, V writer[K]] interface {
+	ReadAll() ([]V, error)
+	ReadOne(K) (V, error)
+
+	Create(V) (V, error)
+	Update(K, V) (V, error)
+	Delete(K) error
+
+	Commit() error
+	CommitContext(context.Context) error
+	Rollback() error
+}
+
+type tx[K comparable, V writer[K]] struct {
+	s *fileWriter[K, V]
+	records[K, V]
+	changes    []change[K, V]
+	generation uint64
+	done       bool
+}
+
+// start a transaction on a copy of the records in the storer
+func (s *fileWriter[K, V]) Begin() (Tx[K, V], error) {
+	return s.BeginContext(context.Background())
+}
+
+// start a transaction like Begin, giving up when ctx is done before the
+// storer can be read
+func (s *fileWriter[K, V]) BeginContext(ctx context.Context) (Tx[K, V], error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mutex.RUnlock()
+
+	if s.closed {
+		return nil, ErrorClosed
+	}
+
+	return &tx[K, V]{
+		s:          s,
+		records:    s.records.copy(),
+		generation: s.generation,
+	}, nil
+}
+
+// read all records as seen by the transaction. The records are copies, so
+// changing them does not change the writer before the transaction commits.
+func (t *tx[K, V]) ReadAll() ([]V, error) {
+	if t.done {
+		return nil, ErrorTxDone
+	}
+
+	visible := t.s.visibleRecords(t.data)
+	data := make([]V, len(visible))
+	for i, record := range visible {
+		var err error
+		if data[i], err = t.readCopy(record); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// read a record as seen by the transaction, returning a copy like ReadAll
+func (t *tx[K, V]) ReadOne(id K) (V, error) {
+	if t.done {
+		return *new(V), ErrorTxDone
+	}
+
+	data, ok := t.dataMap[id]
+	if !ok || !t.s.visible(data) {
+		return *new(V), ErrorDataNotExists
+	}
+	return t.readCopy(data)
+}
+
+// copy a record for the caller, since the transaction shares the records it
+// has not changed with the writer
+func (t *tx[K, V]) readCopy(data V) (V, error) {
+	if t.s.defensiveCopies {
+		return t.s.clone(data)
+	}
+	return shallowCopy(data), nil
+}
+
+// stage a new record in the transaction
+func (t *tx[K, V]) Create(data V) (V, error) {
+	if t.done {
+		return *new(V), ErrorTxDone
+	}
+
+	c, err := t.s.create(&t.records, data)
+	if err != nil {
+		return *new(V), err
+	}
+	t.changes = append(t.changes, c)
+
+	return t.s.handBack(data, c), nil
+}
+
+// stage an update of an existing record in the transaction
+func (t *tx[K, V]) Update(id K, data V) (V, error) {
+	if t.done {
+		return *new(V), ErrorTxDone
+	}
+
+	c, err := t.s.update(&t.records, id, data)
+	if err != nil {
+		return *new(V), err
+	}
+	t.changes = append(t.changes, c)
+
+	return t.s.handBack(data, c), nil
+}
+
+// stage a delete of an existing record in the transaction
+func (t *tx[K, V]) Delete(id K) error {
+	if t.done {
+		return ErrorTxDone
+	}
+
+	c, err := t.s.delete(&t.records, id)
+	if err != nil {
+		return err
+	}
+	t.changes = append(t.changes, c)
+
+	return nil
+}
+
+// apply the staged changes to the storer and write them in a single batch.
+// Commit fails with ErrorTxConflict when the storer was changed after the
+// transaction began, and leaves the storer untouched when writing fails.
+func (t *tx[K, V]) Commit() error {
+	return t.CommitContext(context.Background())
+}
+
+// commit the transaction like Commit, giving up when ctx is done before the
+// changes are applied. The transaction is finished either way.
+func (t *tx[K, V]) CommitContext(ctx context.Context) error {
+	if t.done {
+		return ErrorTxDone
+	}
+	t.done = true
+
+	if len(t.changes) == 0 {
+		return nil
+	}
+
+	s := t.s
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if s.generation != t.generation {
+		return ErrorTxConflict
+	}
+
+	return s.persistRecords(t.records, t.changes)
+}
+
+// discard the staged changes
+func (t *tx[K, V]) Rollback() error {
+	if t.done {
+		return ErrorTxDone
+	}
+	t.done = true
+
+	return nil
+}
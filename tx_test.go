@@ -0,0 +1,157 @@
+package gofilestorer
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterTx(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataString, data *testJSONDataString) string {
+		return data.ID
+	}
+
+	s, err := NewJSONWriter[string, *testJSONDataString](fs, "./string.json", newIdFunc)
+	assert.NoError(t, err)
+
+	// Commit
+	tx, err := s.Begin()
+	assert.NoError(t, err)
+	_, err = tx.Create(&testJSONDataString{ID: "first"})
+	assert.NoError(t, err)
+	_, err = tx.Create(&testJSONDataString{ID: "second"})
+	assert.NoError(t, err)
+	err = tx.Delete("foobar")
+	assert.NoError(t, err)
+
+	// Changes are visible within the transaction only
+	read, err := tx.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	_, err = tx.ReadOne("foobar")
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	_, err = s.ReadOne("first")
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+
+	err = tx.Commit()
+	assert.NoError(t, err)
+
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "first", read[0].ID)
+	assert.Equal(t, "second", read[1].ID)
+
+	r, err := NewJSONReader[string, *testJSONDataString](fs, "./string.json")
+	assert.NoError(t, err)
+	read, err = r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+
+	// Finished transactions cannot be used again
+	_, err = tx.Create(&testJSONDataString{ID: "third"})
+	assert.ErrorIs(t, err, ErrorTxDone)
+	assert.ErrorIs(t, tx.Commit(), ErrorTxDone)
+
+	// Rollback
+	tx, err = s.Begin()
+	assert.NoError(t, err)
+	err = tx.Delete("first")
+	assert.NoError(t, err)
+	err = tx.Rollback()
+	assert.NoError(t, err)
+
+	_, err = s.ReadOne("first")
+	assert.NoError(t, err)
+
+	// Failed operations can be handled within the transaction
+	tx, err = s.Begin()
+	assert.NoError(t, err)
+	err = tx.Delete("missing")
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+	err = tx.Rollback()
+	assert.NoError(t, err)
+
+	// Conflict with a change made after the transaction began
+	tx, err = s.Begin()
+	assert.NoError(t, err)
+	err = tx.Delete("first")
+	assert.NoError(t, err)
+	_, err = s.Create(&testJSONDataString{ID: "third"})
+	assert.NoError(t, err)
+	err = tx.Commit()
+	assert.ErrorIs(t, err, ErrorTxConflict)
+
+	_, err = s.ReadOne("first")
+	assert.NoError(t, err)
+}
+
+func TestWriterTxWriteFailure(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataString, data *testJSONDataString) string {
+		return data.ID
+	}
+
+	s, err := NewJSONWriter[string, *testJSONDataString](afero.NewReadOnlyFs(fs), "./string.json", newIdFunc)
+	assert.NoError(t, err)
+
+	tx, err := s.Begin()
+	assert.NoError(t, err)
+	_, err = tx.Create(&testJSONDataString{ID: "first"})
+	assert.NoError(t, err)
+	err = tx.Delete("foobar")
+	assert.NoError(t, err)
+
+	// Nothing is applied when the write fails
+	err = tx.Commit()
+	assert.Error(t, err)
+
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 1)
+	assert.Equal(t, "foobar", read[0].ID)
+}
+
+func TestWriterTxRollbackReadRecord(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataVersioned, data *testJSONDataVersioned) string {
+		return data.ID
+	}
+
+	s, err := NewJSONWriter[string, *testJSONDataVersioned](fs, "./string.json", newIdFunc)
+	assert.NoError(t, err)
+	_, err = s.Create(&testJSONDataVersioned{testJSONDataString: testJSONDataString{ID: "a", Name: "a"}})
+	assert.NoError(t, err)
+
+	// Read, modify and update a record in a transaction, then roll back
+	tx, err := s.Begin()
+	assert.NoError(t, err)
+	data, err := tx.ReadOne("a")
+	assert.NoError(t, err)
+	data.Name = "changed"
+	_, err = tx.Update("a", data)
+	assert.NoError(t, err)
+	read, err := tx.ReadAll()
+	assert.NoError(t, err)
+	read[0].Name = "changed again"
+	err = tx.Rollback()
+	assert.NoError(t, err)
+
+	// The writer still has the record as written to the file
+	stored, err := s.ReadOne("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", stored.Name)
+	assert.Equal(t, int64(1), stored.Version)
+	assert.Nil(t, stored.UpdatedAt)
+	err = s.DeleteIfVersion("a", 1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,116 @@
+package gofilestorer
+
+import (
+	"context"
+	"fmt"
+)
+
+// versioner is implemented by records that carry a version number. Writers
+// increment the version on every change, which allows UpdateIfVersion and
+// DeleteIfVersion to detect concurrent changes.
+type versioner interface {
+	GetVersion() int64
+	SetVersion(int64)
+}
+
+// VersionConflictError is returned when the stored version of a record does
+// not match the expected version
+type VersionConflictError struct {
+	ID       any
+	Expected int64
+	Actual   int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s: %v has version %d, expected %d", ErrorVersionConflict, e.ID, e.Actual, e.Expected)
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrorVersionConflict
+}
+
+// set the version of data to follow the version of old, starting at 1 for
+// new records
+func setVersion[V any](old *V, data V) {
+	v, ok := any(data).(versioner)
+	if !ok {
+		return
+	}
+
+	version := int64(1)
+	if old != nil {
+		version = any(*old).(versioner).GetVersion() + 1
+	}
+	v.SetVersion(version)
+}
+
+// check that the record stored under id has the expected version
+func (r *records[K, V]) checkVersion(id K, version int64) error {
+	data, ok := r.dataMap[id]
+	if !ok {
+		return ErrorDataNotExists
+	}
+
+	v, ok := any(data).(versioner)
+	if !ok {
+		return fmt.Errorf("%w: %T does not implement GetVersion and SetVersion", ErrorNotVersioned, data)
+	}
+	if v.GetVersion() != version {
+		return &VersionConflictError{ID: id, Expected: version, Actual: v.GetVersion()}
+	}
+
+	return nil
+}
+
+// update an existing record if its stored version matches version
+func (s *fileWriter[K, V]) UpdateIfVersion(id K, version int64, data V) (V, error) {
+	return s.UpdateIfVersionContext(context.Background(), id, version, data)
+}
+
+// update an existing record like UpdateIfVersion, giving up when ctx is done
+// before the record is updated
+func (s *fileWriter[K, V]) UpdateIfVersionContext(ctx context.Context, id K, version int64, data V) (V, error) {
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return *new(V), err
+	}
+	defer unlock()
+
+	if err := s.checkVersion(id, version); err != nil {
+		return *new(V), err
+	}
+	c, err := s.update(&s.records, id, data)
+	if err != nil {
+		return *new(V), err
+	}
+	if err := s.persist(c); err != nil {
+		return *new(V), err
+	}
+
+	return s.handBack(data, c), nil
+}
+
+// delete an existing record if its stored version matches version
+func (s *fileWriter[K, V]) DeleteIfVersion(id K, version int64) error {
+	return s.DeleteIfVersionContext(context.Background(), id, version)
+}
+
+// delete an existing record like DeleteIfVersion, giving up when ctx is done
+// before the record is deleted
+func (s *fileWriter[K, V]) DeleteIfVersionContext(ctx context.Context, id K, version int64) error {
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.checkVersion(id, version); err != nil {
+		return err
+	}
+	c, err := s.delete(&s.records, id)
+	if err != nil {
+		return err
+	}
+
+	return s.persist(c)
+}
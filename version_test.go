@@ -0,0 +1,94 @@
+package gofilestorer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testJSONDataVersioned struct {
+	testJSONDataString
+	Version int64 `json:"version"`
+}
+
+func (d *testJSONDataVersioned) GetVersion() int64 {
+	return d.Version
+}
+
+func (d *testJSONDataVersioned) SetVersion(version int64) {
+	d.Version = version
+}
+
+func TestWriterVersion(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	newIdFunc := func(dataArray []*testJSONDataVersioned, data *testJSONDataVersioned) string {
+		return data.ID
+	}
+
+	s, err := NewJSONWriter[string, *testJSONDataVersioned](fs, "./string.json", newIdFunc)
+	assert.NoError(t, err)
+
+	// Create starts at version 1
+	data := &testJSONDataVersioned{testJSONDataString: testJSONDataString{ID: "new"}}
+	_, err = s.Create(data)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), data.Version)
+
+	// Update increments the version
+	_, err = s.Update("new", &testJSONDataVersioned{testJSONDataString: testJSONDataString{ID: "new", Name: "updated"}})
+	assert.NoError(t, err)
+	read, err := s.ReadOne("new")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), read.Version)
+
+	// Two handlers update the same version, the second one conflicts
+	first := &testJSONDataVersioned{testJSONDataString: testJSONDataString{ID: "new", Name: "first"}}
+	_, err = s.UpdateIfVersion("new", 2, first)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), first.Version)
+
+	second := &testJSONDataVersioned{testJSONDataString: testJSONDataString{ID: "new", Name: "second"}}
+	_, err = s.UpdateIfVersion("new", 2, second)
+	assert.ErrorIs(t, err, ErrorVersionConflict)
+	var conflict *VersionConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, int64(2), conflict.Expected)
+	assert.Equal(t, int64(3), conflict.Actual)
+
+	read, err = s.ReadOne("new")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", read.Name)
+
+	// Delete with a stale version
+	err = s.DeleteIfVersion("new", 2)
+	assert.ErrorIs(t, err, ErrorVersionConflict)
+	err = s.DeleteIfVersion("new", 3)
+	assert.NoError(t, err)
+	err = s.DeleteIfVersion("new", 3)
+	assert.ErrorIs(t, err, ErrorDataNotExists)
+
+	// Versions are stored in the file
+	s, err = NewJSONWriter[string, *testJSONDataVersioned](fs, "./string.json", newIdFunc)
+	assert.NoError(t, err)
+	read, err = s.ReadOne("foobar")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), read.Version)
+	_, err = s.UpdateIfVersion("foobar", 0, &testJSONDataVersioned{testJSONDataString: testJSONDataString{ID: "foobar"}})
+	assert.NoError(t, err)
+
+	s, err = NewJSONWriter[string, *testJSONDataVersioned](fs, "./string.json", newIdFunc)
+	assert.NoError(t, err)
+	read, err = s.ReadOne("foobar")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), read.Version)
+
+	// Records without versions
+	w, err := NewJSONWriter[string, *testJSONDataString](fs, "./string.json", func(_ []*testJSONDataString, data *testJSONDataString) string {
+		return data.ID
+	})
+	assert.NoError(t, err)
+	err = w.DeleteIfVersion("foobar", 1)
+	assert.ErrorIs(t, err, ErrorNotVersioned)
+}
@@ -0,0 +1,83 @@
+package gofilestorer
+
+import (
+	"context"
+	"time"
+)
+
+// read the file again and swap in its records, keeping the current records
+// when it cannot be read. Pending changes are reapplied on top of it.
+func (s *storer[K, V]) reload() error {
+	if err := s.readFile(); err != nil {
+		return err
+	}
+
+	for _, c := range s.pending {
+		if c.op == opDelete {
+			s.remove(c.id)
+		} else {
+			s.replace(c.id, c.new)
+		}
+	}
+	s.generation++
+
+	return nil
+}
+
+// read the file again, keeping the current records when it cannot be read
+func (s *storer[K, V]) Reload() error {
+	return s.ReloadContext(context.Background())
+}
+
+// read the file again like Reload, giving up when ctx is done before the
+// storer can be locked
+func (s *storer[K, V]) ReloadContext(ctx context.Context) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mutex.Unlock()
+
+	return s.reload()
+}
+
+// start polling the file for changes when the options ask for it
+func (s *storer[K, V]) startWatch() {
+	if s.watchInterval <= 0 {
+		return
+	}
+
+	s.stopWatch = make(chan struct{})
+	s.watchDone = make(chan struct{})
+	go func() {
+		defer close(s.watchDone)
+
+		ticker := time.NewTicker(s.watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.watch()
+			case <-s.stopWatch:
+				return
+			}
+		}
+	}()
+}
+
+// reload the file if it changed and report the result to the watch handler
+func (s *storer[K, V]) watch() {
+	s.mutex.Lock()
+	changed, stamp, err := s.changedOnDisk()
+	if err == nil && changed {
+		if err = s.reload(); err != nil {
+			// Do not report the same broken file again on the next poll
+			s.stamp = stamp
+			s.logger.Printf("error reloading %s: %v", s.fileName, err)
+		}
+	}
+	s.mutex.Unlock()
+
+	if (changed || err != nil) && s.watchHandler != nil {
+		s.watchHandler(err)
+	}
+}
@@ -0,0 +1,84 @@
+package gofilestorer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONReaderReload(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	r, err := NewJSONReader[string, *testJSONDataString](fs, "string.json")
+	assert.NoError(t, err)
+
+	// Reload picks up a changed file
+	err = afero.WriteFile(fs, "string.json", []byte(`[{"id": "foo", "name": "Foo"}, {"id": "bar", "name": "Bar"}]`), 0644)
+	assert.NoError(t, err)
+	err = r.Reload()
+	assert.NoError(t, err)
+
+	read, err := r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+
+	// A broken file keeps the last good records
+	err = afero.WriteFile(fs, "string.json", []byte(`[{"id": "foo"`), 0644)
+	assert.NoError(t, err)
+	err = r.Reload()
+	assert.Error(t, err)
+
+	data, err := r.ReadOne("bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bar", data.Name)
+}
+
+func TestJSONReaderWatch(t *testing.T) {
+	fs := getJSONFilesystem(t)
+
+	reloads := make(chan error, 10)
+	r, err := NewJSONReader[string, *testJSONDataString](fs, "string.json",
+		WithWatch(time.Millisecond, func(err error) { reloads <- err }))
+	assert.NoError(t, err)
+	defer r.Close()
+
+	// A changed file is reloaded
+	err = afero.WriteFile(fs, "string.json", []byte(`[{"id": "foo", "name": "Foo"}, {"id": "bar", "name": "Bar"}]`), 0644)
+	assert.NoError(t, err)
+	select {
+	case err := <-reloads:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("file was not reloaded")
+	}
+
+	data, err := r.ReadOne("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "Foo", data.Name)
+
+	// A broken file is reported once and keeps the last good records
+	err = afero.WriteFile(fs, "string.json", []byte(`[{"id": "foo"`), 0644)
+	assert.NoError(t, err)
+	select {
+	case err := <-reloads:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("reload error was not reported")
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, reloads)
+
+	read, err := r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+
+	// Close stops watching
+	err = r.Close()
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "string.json", []byte(`[]`), 0644)
+	assert.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, reloads)
+}
@@ -0,0 +1,240 @@
+package gofilestorer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// fileWriter implements the Writer methods shared by all file formats
+type fileWriter[K comparable, V writer[K]] struct {
+	storer[K, V]
+}
+
+// read the file and start flushing in the background if needed
+func (s *fileWriter[K, V]) open() error {
+	if err := s.storer.open(); err != nil {
+		return err
+	}
+
+	s.startFlushLoop()
+	return nil
+}
+
+// lock the storer and the file for a change, reloading the file when it was
+// changed by another process. It gives up when ctx is done before the change
+// can be made.
+func (s *fileWriter[K, V]) lockWrite(ctx context.Context) (func(), error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	if s.closed {
+		s.mutex.Unlock()
+		return nil, ErrorClosed
+	}
+
+	unlockFile, err := s.lockFileForWrite(ctx)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		unlockFile()
+		s.mutex.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		unlockFile()
+		s.unlock()
+	}, nil
+}
+
+// create a new record in the storer and write changes to file
+func (s *fileWriter[K, V]) Create(data V) (V, error) {
+	return s.CreateContext(context.Background(), data)
+}
+
+// create a new record like Create, giving up when ctx is done before the
+// record is created
+func (s *fileWriter[K, V]) CreateContext(ctx context.Context, data V) (V, error) {
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return *new(V), err
+	}
+	defer unlock()
+
+	c, err := s.create(&s.records, data)
+	if err != nil {
+		return *new(V), err
+	}
+	if err := s.persist(c); err != nil {
+		return *new(V), err
+	}
+
+	return s.handBack(data, c), nil
+}
+
+// update an existing record in the storer and write changes to file
+func (s *fileWriter[K, V]) Update(id K, data V) (V, error) {
+	return s.UpdateContext(context.Background(), id, data)
+}
+
+// update an existing record like Update, giving up when ctx is done before the
+// record is updated
+func (s *fileWriter[K, V]) UpdateContext(ctx context.Context, id K, data V) (V, error) {
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return *new(V), err
+	}
+	defer unlock()
+
+	c, err := s.update(&s.records, id, data)
+	if err != nil {
+		return *new(V), err
+	}
+	if err := s.persist(c); err != nil {
+		return *new(V), err
+	}
+
+	return s.handBack(data, c), nil
+}
+
+// delete an existing record in the storer and write changes to file
+func (s *fileWriter[K, V]) Delete(id K) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// delete an existing record like Delete, giving up when ctx is done before the
+// record is deleted
+func (s *fileWriter[K, V]) DeleteContext(ctx context.Context, id K) error {
+	unlock, err := s.lockWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	c, err := s.delete(&s.records, id)
+	if err != nil {
+		return err
+	}
+
+	return s.persist(c)
+}
+
+// apply a create to r
+func (s *fileWriter[K, V]) create(r *records[K, V], data V) (change[K, V], error) {
+	return s.createWithID(r, s.newIDFunc(r.data, data), data)
+}
+
+// apply a create of a record with the given id to r. The fields are set on a
+// copy of data, which handBack copies into data once the change is written.
+func (s *fileWriter[K, V]) createWithID(r *records[K, V], id K, data V) (change[K, V], error) {
+	if _, ok := r.dataMap[id]; ok {
+		return change[K, V]{}, fmt.Errorf("%w: %v", ErrorDataExists, id)
+	}
+	data = shallowCopy(data)
+	data.SetID(id)
+	if err := r.checkIndexes(id, data); err != nil {
+		return change[K, V]{}, err
+	}
+	data.SetCreatedAt(s.now())
+	setVersion(nil, data)
+	stored, err := s.clone(data)
+	if err != nil {
+		return change[K, V]{}, err
+	}
+	r.insert(stored)
+
+	return change[K, V]{op: opCreate, id: id, new: stored, result: data}, nil
+}
+
+// apply an update to r, replacing the stored record with data. An empty ID
+// in data is set to id, and the CreatedAt of the stored record is kept when V
+// implements GetCreatedAt. Like createWithID, the fields are set on a copy of
+// data, so that the stored record is never changed when data is the stored
+// record itself.
+func (s *fileWriter[K, V]) update(r *records[K, V], id K, data V) (change[K, V], error) {
+	old, ok := r.dataMap[id]
+	if !ok || !s.visible(old) {
+		return change[K, V]{}, ErrorDataNotExists
+	}
+	data = shallowCopy(data)
+
+	switch data.GetID() {
+	case id:
+	case *new(K):
+		data.SetID(id)
+	default:
+		return change[K, V]{}, fmt.Errorf("%w: %v does not match %v", ErrorIDMismatch, data.GetID(), id)
+	}
+	if err := r.checkIndexes(id, data); err != nil {
+		return change[K, V]{}, err
+	}
+
+	if o, ok := any(old).(createdAtGetter); ok {
+		data.SetCreatedAt(o.GetCreatedAt())
+	}
+	data.SetUpdatedAt(s.now())
+	setVersion(&old, data)
+	stored, err := s.clone(data)
+	if err != nil {
+		return change[K, V]{}, err
+	}
+	r.replace(id, stored)
+
+	return change[K, V]{op: opUpdate, id: id, old: old, new: stored, result: data}, nil
+}
+
+// hand the record changed by c back to the caller that passed data once the
+// change is applied, by copying the fields set by the change into data. When
+// data is the record the change replaced, it is left untouched and the
+// changed copy is returned instead.
+func (s *fileWriter[K, V]) handBack(data V, c change[K, V]) V {
+	if reflect.ValueOf(data).Kind() != reflect.Pointer || sameRecord(data, c.old) {
+		return c.result
+	}
+
+	reflect.ValueOf(data).Elem().Set(reflect.ValueOf(c.result).Elem())
+	return data
+}
+
+// apply a delete to r, which only marks the record as deleted with
+// WithSoftDelete
+func (s *fileWriter[K, V]) delete(r *records[K, V], id K) (change[K, V], error) {
+	old, ok := r.dataMap[id]
+	if !ok || !s.visible(old) {
+		return change[K, V]{}, ErrorDataNotExists
+	}
+	if s.softDelete {
+		return s.markDeleted(r, id, old)
+	}
+	index := r.remove(id)
+
+	return change[K, V]{op: opDelete, id: id, old: old, index: index}, nil
+}
+
+// write the snapshot file and clear the journal
+func (s *fileWriter[K, V]) Compact() error {
+	return s.CompactContext(context.Background())
+}
+
+// write the snapshot file and clear the journal, giving up when ctx is done
+// before the storer and the file can be locked
+func (s *fileWriter[K, V]) CompactContext(ctx context.Context) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.unlock()
+
+	unlock, err := s.lockFileForWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+	return s.compact()
+}
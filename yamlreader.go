@@ -0,0 +1,44 @@
+package gofilestorer
+
+import (
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+type yamlReader[K comparable, V reader[K]] struct {
+	storer[K, V]
+}
+
+// Create a new reader that is backed by a YAML file
+func NewYAMLReader[K comparable, V reader[K]](fs afero.Fs, fileName string, opts ...Option) (Reader[K, V], error) {
+	s := &yamlReader[K, V]{
+		storer: storer[K, V]{
+			fs:       fs,
+			fileName: fileName,
+			codec:    yamlCodec[V]{},
+			options:  newOptions(opts),
+		},
+	}
+
+	// Read file
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+type yamlCodec[V any] struct{}
+
+// unmarshal the YAML sequence into records. An empty document holds no
+// records.
+func (yamlCodec[V]) decode(dataBytes []byte) ([]V, error) {
+	data := []V{}
+	if err := yaml.Unmarshal(dataBytes, &data); err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = []V{}
+	}
+	return data, nil
+}
@@ -0,0 +1,132 @@
+package gofilestorer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func getYAMLFilesystem(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	// create test files and directories
+	err := fs.MkdirAll("data", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "uuid.yaml", []byte(`# seed data
+- id: e21ab9b3-bb4e-4921-815b-41de7980c5da
+  created_at: 2022-12-27T12:45:51.8347046-08:00
+  name: Foobar
+- id: 0b3e3a1c-2f41-4f5e-8a0e-3d1f1b8f6c2a
+  created_at: 2022-12-28T12:45:51.8347046-08:00
+  name: Barfoo
+`), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "data/invalid.yaml", []byte(`- id: [`), 0644)
+	assert.NoError(t, err)
+
+	return fs
+}
+
+type testYAMLData struct {
+	ID        uuid.UUID  `yaml:"id"`
+	CreatedAt time.Time  `yaml:"created_at"`
+	UpdatedAt *time.Time `yaml:"updated_at,omitempty"`
+	Name      string     `yaml:"name"`
+}
+
+func (d *testYAMLData) GetID() uuid.UUID {
+	return d.ID
+}
+
+func (d *testYAMLData) SetID(id uuid.UUID) {
+	d.ID = id
+}
+
+func (d *testYAMLData) GetCreatedAt() time.Time {
+	return d.CreatedAt
+}
+
+func (d *testYAMLData) SetCreatedAt(createdAt time.Time) {
+	d.CreatedAt = createdAt
+}
+
+func (d *testYAMLData) SetUpdatedAt(updatedAt time.Time) {
+	d.UpdatedAt = &updatedAt
+}
+
+func TestYAMLReader(t *testing.T) {
+	fs := getYAMLFilesystem(t)
+
+	// Read non-existant file
+	s, err := NewYAMLReader[uuid.UUID, *testYAMLData](fs, "./foobar.yaml")
+	assert.Error(t, err)
+	assert.Nil(t, s)
+
+	// Read invalid file
+	s, err = NewYAMLReader[uuid.UUID, *testYAMLData](fs, "./data/invalid.yaml")
+	assert.Error(t, err)
+	assert.Nil(t, s)
+
+	// Read test file
+	s, err = NewYAMLReader[uuid.UUID, *testYAMLData](fs, "./uuid.yaml")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	// Read
+	read, err := s.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "e21ab9b3-bb4e-4921-815b-41de7980c5da", read[0].ID.String())
+	assert.Equal(t, "Foobar", read[0].Name)
+	assert.NotEmpty(t, read[0].CreatedAt)
+}
+
+func TestYAMLWriter(t *testing.T) {
+	fs := getYAMLFilesystem(t)
+
+	newIdFunc := func(_ []*testYAMLData, _ *testYAMLData) uuid.UUID {
+		return uuid.New()
+	}
+
+	s, err := NewYAMLWriter[uuid.UUID, *testYAMLData](fs, "./uuid.yaml", newIdFunc)
+	assert.NoError(t, err)
+
+	// Create
+	data, err := s.Create(&testYAMLData{Name: "new"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, data.ID)
+
+	// Update
+	_, err = s.Update(uuid.MustParse("e21ab9b3-bb4e-4921-815b-41de7980c5da"), &testYAMLData{Name: "updated"})
+	assert.NoError(t, err)
+
+	// Delete
+	err = s.Delete(uuid.MustParse("0b3e3a1c-2f41-4f5e-8a0e-3d1f1b8f6c2a"))
+	assert.NoError(t, err)
+
+	// The file is rewritten in order using the yaml tags
+	dataBytes, err := afero.ReadFile(fs, "./uuid.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(dataBytes), "- id: e21ab9b3-bb4e-4921-815b-41de7980c5da\n  created_at: 2022-12-27T12:45:51.8347046-08:00\n")
+	assert.Contains(t, string(dataBytes), "  name: updated\n- id: "+data.ID.String())
+
+	// Reopen
+	r, err := NewYAMLReader[uuid.UUID, *testYAMLData](fs, "./uuid.yaml")
+	assert.NoError(t, err)
+	read, err := r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, "updated", read[0].Name)
+	assert.NotNil(t, read[0].UpdatedAt)
+	assert.Equal(t, data.ID, read[1].ID)
+	assert.True(t, data.CreatedAt.Equal(read[1].CreatedAt))
+
+	// Create missing file
+	s, err = NewYAMLWriter[uuid.UUID, *testYAMLData](fs, "./missing/data.yaml", newIdFunc, WithCreateIfMissing())
+	assert.NoError(t, err)
+	read, err = s.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, read)
+}
@@ -0,0 +1,48 @@
+package gofilestorer
+
+import (
+	"bytes"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+type yamlWriter[K comparable, V writer[K]] struct {
+	fileWriter[K, V]
+}
+
+// Create a new writer that is backed by a YAML file
+func NewYAMLWriter[K comparable, V writer[K]](fs afero.Fs, fileName string, newIDFunc func([]V, V) K, opts ...Option) (Writer[K, V], error) {
+	s := &yamlWriter[K, V]{
+		fileWriter: fileWriter[K, V]{
+			storer: storer[K, V]{
+				fs:        fs,
+				fileName:  fileName,
+				newIDFunc: newIDFunc,
+				codec:     yamlCodec[V]{},
+				options:   newOptions(opts),
+			},
+		},
+	}
+
+	// Read file
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// marshal the records into a YAML sequence in file order
+func (yamlCodec[V]) encode(data []V) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(data); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}